@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+)
+
+type CacheClearer interface {
+	Clear() error
+	Dir() string
+}
+
+func NewCacheCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "cache",
+		Short: "Manage the cache of LLM responses",
+	}
+}
+
+func NewCacheClearCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "clear",
+		Short: "Delete all cached LLM responses",
+	}
+}
+
+func RunCacheClear(cache CacheClearer, writer io.Writer) error {
+	if err := cache.Clear(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(writer, "Cleared %s\n", cache.Dir())
+	return nil
+}
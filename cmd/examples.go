@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/brognilucas/ai-changelog/internal/changelog"
+	"github.com/brognilucas/ai-changelog/internal/git"
+	"github.com/brognilucas/ai-changelog/internal/llm"
+)
+
+const DefaultMaxExamples = 2
+
+// loadExamples pairs the latest releases of the changelog in opts.ExamplesFrom with the commits
+// between their tag and the previous one, so the model sees how earlier notes were written.
+// Examples are optional: problems are reported as warnings and the run goes on without them.
+func loadExamples(ctx context.Context, deps GenerateDeps, opts GenerateOptions) []llm.Example {
+	if opts.ExamplesFrom == "" || opts.MaxExamples <= 0 || deps.LLMClient == nil {
+		return nil
+	}
+	if deps.TagLister == nil {
+		fmt.Fprintln(os.Stderr, "Warning: few-shot examples require a tag lister, sending none")
+		return nil
+	}
+
+	content, err := os.ReadFile(opts.ExamplesFrom)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to read examples: %v\n", err)
+		return nil
+	}
+
+	tags, err := deps.TagLister.ListTags(ctx, opts.TagPattern, opts.SkipPrerelease)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to list tags for examples: %v\n", err)
+		return nil
+	}
+
+	var examples []llm.Example
+	for _, release := range changelog.ParseReleases(string(content)) {
+		if len(examples) == opts.MaxExamples {
+			break
+		}
+		if sameVersion(release.Version, opts.Version) || sameVersion(release.Version, opts.Until) {
+			continue
+		}
+
+		index := releaseTag(tags, release.Version)
+		if index < 0 {
+			continue
+		}
+		commitRange := git.CommitRange{Until: tags[index].Name}
+		if index > 0 {
+			commitRange.Since = tags[index-1].Name
+		}
+
+		commits, err := deps.CommitReader.GetCommits(ctx, commitRange)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to get commits for example %s: %v\n", release.Version, err)
+			continue
+		}
+		if len(commits) == 0 {
+			continue
+		}
+
+		examples = append(examples, llm.Example{
+			Version:   tags[index].Name,
+			Commits:   redactCommits(opts, commits),
+			Changelog: release.Body,
+		})
+	}
+
+	if len(examples) == 0 {
+		fmt.Fprintf(os.Stderr, "Warning: no release in %s matches a tag, sending no examples\n", opts.ExamplesFrom)
+	}
+	return examples
+}
+
+func releaseTag(tags []git.Tag, version string) int {
+	for i, tag := range tags {
+		if sameVersion(tag.Name, version) {
+			return i
+		}
+	}
+	return -1
+}
+
+func sameVersion(a string, b string) bool {
+	return a != "" && b != "" && strings.TrimPrefix(a, "v") == strings.TrimPrefix(b, "v")
+}
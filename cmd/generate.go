@@ -1,85 +1,599 @@
 package cmd
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"slices"
 	"strings"
+	"time"
 
+	"github.com/brognilucas/ai-changelog/internal/cache"
 	"github.com/brognilucas/ai-changelog/internal/changelog"
 	"github.com/brognilucas/ai-changelog/internal/git"
+	"github.com/brognilucas/ai-changelog/internal/llm"
 	"github.com/brognilucas/ai-changelog/internal/ollama"
+	"github.com/brognilucas/ai-changelog/internal/provider"
+	"github.com/brognilucas/ai-changelog/internal/redact"
 )
 
 type CommitReader interface {
-	GetCommits(since string) ([]git.Commit, error)
+	GetCommits(ctx context.Context, commitRange git.CommitRange) ([]git.Commit, error)
+}
+
+type TagResolver interface {
+	LatestTag(ctx context.Context, target string, pattern string, skipPrerelease bool) (string, error)
+}
+
+type TagLister interface {
+	ListTags(ctx context.Context, pattern string, skipPrerelease bool) ([]git.Tag, error)
+}
+
+type ResponseCache interface {
+	Get(key string) (string, bool)
+	Put(key string, value string) error
 }
 
 type GenerateDeps struct {
 	CommitReader CommitReader
-	OllamaClient ollama.Client
+	LLMClient    llm.Client
+	TagResolver  TagResolver
+	TagLister    TagLister
+	Cache        ResponseCache
+}
+
+type GenerateOptions struct {
+	Format         string
+	Since          string
+	Until          string
+	Model          string
+	Version        string
+	GroupBy        string
+	TagPattern     string
+	SkipPrerelease bool
+	Prepend        bool
+	Force          bool
+	Date           time.Time
+	RepositoryURL  string
+	Stream         bool
+	WarmUp         bool
+	Pull           bool
+	ModelOptions   *llm.ModelOptions
+	PromptTemplate *llm.PromptTemplate
+	LLMOutput      string
+	Sections       []string
+	Uncited        string
+	Strict         bool
+	MinCoverage    float64
+	Redactor       *redact.Redactor
+	Verbose        bool
+	ExamplesFrom   string
+	MaxExamples    int
+	Examples       []llm.Example
+	Refinements    []string
+	Provider       string
+	Endpoint       string
+	OllamaAPI      string
+}
+
+const (
+	FormatMarkdown       = "markdown"
+	FormatPlain          = "plain"
+	FormatKeepAChangelog = "keepachangelog"
+	FormatJSON           = "json"
+)
+
+// LLMOutputJSON asks the LLM for a StructuredChangelog, which is validated and rendered in
+// any output format; LLMOutputMarkdown prints the model's Markdown as is.
+const (
+	LLMOutputMarkdown = "markdown"
+	LLMOutputJSON     = "json"
+)
+
+const (
+	GroupByCategory = "category"
+	GroupByScope    = "scope"
+	SinceAuto       = "auto"
+)
+
+// Validate rejects option values that are not one of the documented choices, so a typo such as
+// --uncited=keep fails instead of behaving like the default. Empty values mean the default.
+func (opts GenerateOptions) Validate() error {
+	choices := []struct {
+		flag    string
+		value   string
+		allowed []string
+	}{
+		{"format", opts.Format, []string{FormatMarkdown, FormatPlain, FormatKeepAChangelog, FormatJSON}},
+		{"group-by", opts.GroupBy, []string{GroupByCategory, GroupByScope}},
+		{"llm-output", opts.LLMOutput, []string{LLMOutputMarkdown, LLMOutputJSON}},
+		{"uncited", opts.Uncited, []string{UncitedDrop, UncitedFlag}},
+		{"ollama-api", opts.OllamaAPI, []string{ollama.APIChat, ollama.APIGenerate}},
+	}
+
+	for _, choice := range choices {
+		if choice.value == "" {
+			continue
+		}
+		if err := CheckChoice(choice.flag, choice.value, choice.allowed...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CheckChoice returns an error listing the allowed values when value is not one of them.
+func CheckChoice(flag string, value string, allowed ...string) error {
+	if slices.Contains(allowed, value) {
+		return nil
+	}
+	return fmt.Errorf("invalid --%s %q (expected %s)", flag, value, strings.Join(allowed, ", "))
 }
 
-func RunGenerate(deps GenerateDeps, format string, since string, model string, version string, writer io.Writer) error {
-	commits, err := deps.CommitReader.GetCommits(since)
+func RunGenerate(ctx context.Context, deps GenerateDeps, opts GenerateOptions, writer io.Writer) error {
+	opts, err := resolveOptions(ctx, deps, opts)
+	if err != nil {
+		return err
+	}
+
+	commits, err := collectCommits(ctx, deps, opts)
 	if err != nil {
-		return fmt.Errorf("failed to get commits: %w", err)
+		return err
 	}
 
 	if len(commits) == 0 {
+		if opts.Format == FormatJSON {
+			_, err = fmt.Fprint(writer, newRenderer(opts).Render(nil, opts.Version))
+			return err
+		}
 		fmt.Fprintln(writer, "No commits found.")
 		return nil
 	}
 
-	// Try LLM path first
-	if deps.OllamaClient != nil {
-		if err := deps.OllamaClient.HealthCheck(); err == nil {
-			changelogText, llmErr := deps.OllamaClient.GenerateChangelog(commits, model)
-			if llmErr == nil && strings.TrimSpace(changelogText) != "" {
-				var output string
-				if version != "" {
-					output = fmt.Sprintf("# %s\n\n%s", version, changelogText)
-				} else {
-					output = changelogText
-				}
-				_, err = fmt.Fprint(writer, output)
-				return err
-			}
-			if llmErr != nil {
-				fmt.Fprintf(os.Stderr, "Warning: LLM generation failed (%v), falling back to structured output\n", llmErr)
-			}
+	return renderChangelog(ctx, deps, opts, commits, writer)
+}
+
+func renderChangelog(ctx context.Context, deps GenerateDeps, opts GenerateOptions, commits []git.Commit, writer io.Writer) error {
+	if opts.LLMOutput == LLMOutputJSON {
+		return renderStructuredChangelog(ctx, deps, opts, commits, writer)
+	}
+
+	// Try LLM path first; Keep a Changelog output is always structured
+	changelogText := ""
+	if cached, ok := cachedChangelog(deps, opts, commits); ok {
+		changelogText = cached
+	} else if opts.Format != FormatKeepAChangelog {
+		var err error
+		changelogText, err = generateWithLLM(ctx, deps, opts, commits)
+		if err != nil {
+			return err
 		}
 	}
 
-	// Fallback: structured rendering
-	sorted := changelog.SortByDate(commits)
-	sections := changelog.GroupByCategory(sorted)
+	var verification changelog.Verification
+	if changelogText != "" {
+		changelogText, verification = changelog.VerifyMarkdown(changelogText, commits, opts.Uncited == UncitedFlag)
+		if !acceptVerification(opts, verification) {
+			changelogText = ""
+		}
+	}
 
-	var renderer changelog.Renderer
-	if format == "plain" {
-		renderer = &changelog.PlainTextRenderer{}
-	} else {
-		renderer = &changelog.MarkdownRenderer{}
+	if changelogText != "" && opts.Format != FormatJSON {
+		var output string
+		if opts.Version != "" {
+			output = fmt.Sprintf("# %s\n\n%s", opts.Version, changelogText)
+		} else {
+			output = changelogText
+		}
+		_, err := fmt.Fprint(writer, output)
+		return err
 	}
 
-	output := renderer.Render(sections, version)
-	_, err = fmt.Fprint(writer, output)
+	// Fallback: structured rendering (JSON always carries the structured sections)
+	sections := groupCommits(opts, changelog.SortByDate(commits))
+	renderer := newRenderer(opts)
+	if jsonRenderer, ok := renderer.(*changelog.JSONRenderer); ok && changelogText != "" {
+		jsonRenderer.Mode = changelog.ModeLLM
+		jsonRenderer.Model = opts.Model
+		jsonRenderer.LLMText = changelogText
+		jsonRenderer.LLMCommits = commitHashes(commits)
+		jsonRenderer.LLMOptions = jsonModelOptions(opts.ModelOptions)
+		jsonRenderer.LLMVerification = &verification
+	}
+
+	output := renderer.Render(sections, opts.Version)
+	_, err := fmt.Fprint(writer, output)
 	return err
 }
 
-func WriteToFile(deps GenerateDeps, format string, since string, model string, version string, path string) error {
+// generateWithLLM returns an empty string when the structured fallback should be used.
+// The only error it returns is cancellation, which must stop the run instead.
+func generateWithLLM(ctx context.Context, deps GenerateDeps, opts GenerateOptions, commits []git.Commit) (string, error) {
+	if deps.LLMClient == nil {
+		return "", nil
+	}
+
+	if err := deps.LLMClient.HealthCheck(ctx); err != nil {
+		return "", ctx.Err()
+	}
+	if !ensureModel(ctx, deps, opts) {
+		return "", ctx.Err()
+	}
+	warmUpModel(ctx, deps, opts)
+
+	var changelogText string
+	var err error
+	if streamer, ok := deps.LLMClient.(llm.StreamingClient); ok && opts.Stream {
+		changelogText, err = streamWithProgress(ctx, streamer, changelogRequest(opts, commits))
+	} else {
+		changelogText, err = deps.LLMClient.GenerateChangelog(ctx, changelogRequest(opts, commits))
+	}
+	if err != nil {
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		fmt.Fprintf(os.Stderr, "Warning: LLM generation failed (%v), falling back to structured output\n", err)
+		return "", nil
+	}
+
+	if strings.TrimSpace(changelogText) == "" {
+		return "", nil
+	}
+	if opts.LLMOutput == LLMOutputJSON {
+		if _, err := llm.ParseStructuredChangelog(changelogText, llmSections(opts)); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v, falling back to structured output\n", err)
+			return "", nil
+		}
+	} else if err := changelog.CheckStructure(changelogText, promptSections(opts)); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v, falling back to structured output\n", err)
+		return "", nil
+	}
+	storeChangelog(deps, opts, commits, changelogText)
+	return changelogText, nil
+}
+
+// streamWithProgress shows on stderr how much of the response has arrived. Nothing is printed
+// to the output until the complete response has been checked like a non-streamed one.
+func streamWithProgress(ctx context.Context, streamer llm.StreamingClient, request llm.ChangelogRequest) (string, error) {
+	tokens := 0
+	changelogText, err := streamer.GenerateChangelogStream(ctx, request, func(token string) {
+		tokens++
+		fmt.Fprintf(os.Stderr, "\rGenerating changelog: %d tokens", tokens)
+	})
+	if tokens > 0 {
+		fmt.Fprint(os.Stderr, "\r\033[K")
+	}
+	return changelogText, err
+}
+
+// changelogCacheKey covers everything that shapes the response: the backend and model, the
+// prompt template and what it can reference, the sampling options and the commits.
+func changelogCacheKey(opts GenerateOptions, commits []git.Commit) string {
+	options, _ := json.Marshal(opts.ModelOptions)
+	template := opts.PromptTemplate
+	if template == nil {
+		template = llm.DefaultPromptTemplate()
+	}
+	parts := []string{"changelog", opts.Provider, opts.Endpoint, opts.OllamaAPI, opts.Model, template.Source, string(options), opts.Version, opts.Since, opts.Until}
+	if opts.LLMOutput == LLMOutputJSON {
+		parts = append(parts, LLMOutputJSON, strings.Join(llmSections(opts), "\n"))
+	}
+	if opts.Redactor != nil {
+		parts = append(parts, "redact", opts.Redactor.String())
+	}
+	for _, example := range opts.Examples {
+		parts = append(parts, "example", example.Changelog)
+		parts = append(parts, commitHashes(example.Commits)...)
+	}
+	if len(opts.Refinements) > 0 {
+		parts = append(parts, "refine")
+		parts = append(parts, opts.Refinements...)
+	}
+	return cache.Key(append(parts, commitHashes(commits)...)...)
+}
+
+func changelogRequest(opts GenerateOptions, commits []git.Commit) llm.ChangelogRequest {
+	return llm.ChangelogRequest{
+		Commits:     redactCommits(opts, commits),
+		Model:       opts.Model,
+		Version:     opts.Version,
+		Since:       opts.Since,
+		Until:       opts.Until,
+		Template:    opts.PromptTemplate,
+		Sections:    llmSections(opts),
+		Examples:    opts.Examples,
+		Refinements: opts.Refinements,
+		Warnings:    os.Stderr,
+	}
+}
+
+// redactCommits masks secrets and personal data before the commits are sent to the LLM, which
+// may run on a shared server. Only the prompt is affected; fallback output shows the commits
+// as they are.
+func redactCommits(opts GenerateOptions, commits []git.Commit) []git.Commit {
+	if opts.Redactor == nil {
+		return commits
+	}
+
+	redacted, redactions := opts.Redactor.Commits(commits)
+	if opts.Verbose {
+		for _, redaction := range redactions {
+			fmt.Fprintf(os.Stderr, "Redacted %d %s value(s) in %s\n", redaction.Count, redaction.Kind, git.ShortHash(redaction.Hash))
+		}
+	}
+	return redacted
+}
+
+// llmSections are the sections a JSON changelog may use: --sections, or the sections of the
+// output format.
+func llmSections(opts GenerateOptions) []string {
+	switch {
+	case opts.LLMOutput != LLMOutputJSON:
+		return nil
+	case len(opts.Sections) > 0:
+		return opts.Sections
+	case opts.Format == FormatKeepAChangelog:
+		return changelog.KeepAChangelogSections()
+	default:
+		return llm.DefaultSections
+	}
+}
+
+// promptSections are the sections the prompt template asks the LLM for.
+func promptSections(opts GenerateOptions) []string {
+	if opts.PromptTemplate == nil {
+		return llm.DefaultPromptTemplate().Sections()
+	}
+	return opts.PromptTemplate.Sections()
+}
+
+func cachedChangelog(deps GenerateDeps, opts GenerateOptions, commits []git.Commit) (string, bool) {
+	if deps.Cache == nil || deps.LLMClient == nil || (opts.Format == FormatKeepAChangelog && opts.LLMOutput != LLMOutputJSON) {
+		return "", false
+	}
+	return deps.Cache.Get(changelogCacheKey(opts, commits))
+}
+
+func storeChangelog(deps GenerateDeps, opts GenerateOptions, commits []git.Commit, changelogText string) {
+	if deps.Cache == nil {
+		return
+	}
+	if err := deps.Cache.Put(changelogCacheKey(opts, commits), changelogText); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+}
+
+// ensureModel reports whether generation should go ahead, pulling a missing model when
+// opts.Pull is set. A server that cannot list its models is given the benefit of the doubt.
+func ensureModel(ctx context.Context, deps GenerateDeps, opts GenerateOptions) bool {
+	manager, ok := deps.LLMClient.(llm.ModelManager)
+	if !ok {
+		return true
+	}
+
+	err := manager.CheckModel(ctx, opts.Model)
+	if err == nil {
+		return true
+	}
+	if !errors.Is(err, ollama.ErrModelNotFound) {
+		return ctx.Err() == nil
+	}
+
+	if !opts.Pull {
+		fmt.Fprintf(os.Stderr, "Warning: model %s is not available (run: ollama pull %s, or pass --pull), falling back to structured output\n", opts.Model, opts.Model)
+		return false
+	}
+
+	fmt.Fprintf(os.Stderr, "Pulling model %s...\n", opts.Model)
+	if err := manager.PullModel(ctx, opts.Model, os.Stderr); err != nil {
+		if ctx.Err() == nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v, falling back to structured output\n", err)
+		}
+		return false
+	}
+	return true
+}
+
+func warmUpModel(ctx context.Context, deps GenerateDeps, opts GenerateOptions) {
+	warmer, ok := deps.LLMClient.(llm.WarmUpper)
+	if !ok || !opts.WarmUp {
+		return
+	}
+
+	if err := warmer.WarmUp(ctx, opts.Model); err != nil && ctx.Err() == nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+}
+
+func jsonModelOptions(options *llm.ModelOptions) *changelog.JSONModelOptions {
+	if options == nil {
+		return nil
+	}
+	return &changelog.JSONModelOptions{
+		Temperature: options.Temperature,
+		Seed:        options.Seed,
+		TopP:        options.TopP,
+		NumCtx:      options.NumCtx,
+		NumPredict:  options.NumPredict,
+	}
+}
+
+func commitHashes(commits []git.Commit) []string {
+	hashes := make([]string, 0, len(commits))
+	for _, commit := range commits {
+		hashes = append(hashes, commit.Hash)
+	}
+	return hashes
+}
+
+func groupCommits(opts GenerateOptions, commits []git.Commit) []changelog.ChangelogSection {
+	switch {
+	case opts.Format == FormatKeepAChangelog:
+		return changelog.GroupByKeepAChangelog(commits)
+	case opts.GroupBy == GroupByScope:
+		return changelog.GroupByCategoryAndScope(commits)
+	default:
+		return changelog.GroupByCategory(commits)
+	}
+}
+
+func newRenderer(opts GenerateOptions) changelog.Renderer {
+	switch opts.Format {
+	case FormatPlain:
+		return &changelog.PlainTextRenderer{}
+	case FormatKeepAChangelog:
+		return &changelog.KeepAChangelogRenderer{
+			Date:            opts.Date,
+			PreviousVersion: previousVersion(opts.Since),
+			RepositoryURL:   opts.RepositoryURL,
+		}
+	case FormatJSON:
+		return &changelog.JSONRenderer{
+			Date:  opts.Date,
+			Since: opts.Since,
+			Until: opts.Until,
+			Mode:  changelog.ModeFallback,
+		}
+	default:
+		return &changelog.MarkdownRenderer{}
+	}
+}
+
+func previousVersion(since string) string {
+	if since == "" || git.IsDate(since) || strings.Contains(since, "..") {
+		return ""
+	}
+	return since
+}
+
+func resolveOptions(ctx context.Context, deps GenerateDeps, opts GenerateOptions) (GenerateOptions, error) {
+	since, err := ResolveSince(ctx, deps, opts)
+	if err != nil {
+		return opts, err
+	}
+
+	opts.Since = since
+	// Examples can also be set directly instead of loaded from ExamplesFrom.
+	if opts.Examples == nil {
+		opts.Examples = loadExamples(ctx, deps, opts)
+	}
+	return opts, nil
+}
+
+func collectCommits(ctx context.Context, deps GenerateDeps, opts GenerateOptions) ([]git.Commit, error) {
+	commits, err := deps.CommitReader.GetCommits(ctx, git.CommitRange{Since: opts.Since, Until: opts.Until})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get commits: %w", err)
+	}
+
+	return commits, nil
+}
+
+func ResolveSince(ctx context.Context, deps GenerateDeps, opts GenerateOptions) (string, error) {
+	if opts.Since != SinceAuto {
+		return opts.Since, nil
+	}
+
+	if deps.TagResolver == nil {
+		return "", fmt.Errorf("--since %s requires a tag resolver", SinceAuto)
+	}
+
+	target := git.CommitRange{Until: opts.Until}.Target()
+	tag, err := deps.TagResolver.LatestTag(ctx, target, opts.TagPattern, opts.SkipPrerelease)
+	if err != nil {
+		return "", fmt.Errorf("failed to find latest tag: %w", err)
+	}
+
+	if tag == "" {
+		fmt.Fprintln(os.Stderr, "Warning: no matching tag found, using the whole history")
+	}
+
+	return tag, nil
+}
+
+func WriteToFile(ctx context.Context, deps GenerateDeps, opts GenerateOptions, path string) error {
+	if opts.Prepend {
+		return PrependToFile(ctx, deps, opts, path)
+	}
+
 	file, err := os.Create(path)
 	if err != nil {
 		return fmt.Errorf("failed to create output file: %w", err)
 	}
 	defer file.Close()
 
-	return RunGenerate(deps, format, since, model, version, file)
+	return RunGenerate(ctx, deps, opts, file)
 }
 
-func CheckOllamaHealth(client ollama.Client) error {
-	if err := client.HealthCheck(); err != nil {
+func PrependToFile(ctx context.Context, deps GenerateDeps, opts GenerateOptions, path string) error {
+	if opts.Format == FormatPlain || opts.Format == FormatJSON {
+		return fmt.Errorf("updating an existing changelog requires markdown output")
+	}
+	if opts.Version == "" {
+		return fmt.Errorf("updating an existing changelog requires a version")
+	}
+
+	existing, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read output file: %w", err)
+	}
+	// Checked before generating, so a run that would be refused does not pay for the LLM call.
+	if !opts.Force && changelog.HasRelease(string(existing), opts.Version) {
+		return fmt.Errorf("%w: %s (use --force to replace it)", changelog.ErrVersionExists, opts.Version)
+	}
+
+	opts, err = resolveOptions(ctx, deps, opts)
+	if err != nil {
+		return err
+	}
+
+	commits, err := collectCommits(ctx, deps, opts)
+	if err != nil {
+		return err
+	}
+
+	if len(commits) == 0 {
+		fmt.Fprintf(os.Stderr, "No commits found, leaving %s unchanged.\n", path)
+		return nil
+	}
+
+	var release bytes.Buffer
+	if err := renderChangelog(ctx, deps, opts, commits, &release); err != nil {
+		return err
+	}
+
+	updated, err := changelog.PrependRelease(string(existing), release.String(), opts.Version, opts.Force)
+	if err != nil {
+		if errors.Is(err, changelog.ErrVersionExists) {
+			return fmt.Errorf("%w (use --force to replace it)", err)
+		}
+		return err
+	}
+
+	if err := os.WriteFile(path, []byte(updated), 0644); err != nil {
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+	return nil
+}
+
+func CheckOllamaHealth(ctx context.Context, client llm.Client) error {
+	if err := client.HealthCheck(ctx); err != nil {
 		return fmt.Errorf("Ollama is not running. Start it with: ollama serve")
 	}
 	return nil
-}
\ No newline at end of file
+}
+
+func CheckLLMHealth(ctx context.Context, client llm.Client, name string) error {
+	if name == "" || name == provider.Ollama {
+		return CheckOllamaHealth(ctx, client)
+	}
+
+	if err := client.HealthCheck(ctx); err != nil {
+		return fmt.Errorf("%s endpoint is not reachable: %w", name, err)
+	}
+	return nil
+}
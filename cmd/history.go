@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/brognilucas/ai-changelog/internal/changelog"
+	"github.com/brognilucas/ai-changelog/internal/git"
+	"github.com/spf13/cobra"
+)
+
+func NewHistoryCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "history",
+		Short: "Generate a changelog with one section per tagged release, newest first",
+	}
+}
+
+func RunHistory(ctx context.Context, deps GenerateDeps, opts GenerateOptions, writer io.Writer) error {
+	if deps.TagLister == nil {
+		return fmt.Errorf("history requires a tag lister")
+	}
+
+	tags, err := deps.TagLister.ListTags(ctx, opts.TagPattern, opts.SkipPrerelease)
+	if err != nil {
+		return fmt.Errorf("failed to list tags: %w", err)
+	}
+
+	if len(tags) == 0 {
+		if opts.Format == FormatJSON {
+			_, err = fmt.Fprint(writer, changelog.RenderJSONHistory(nil))
+			return err
+		}
+		fmt.Fprintln(writer, "No tags found.")
+		return nil
+	}
+
+	var releases []string
+	var versions []string
+	for i := len(tags) - 1; i >= 0; i-- {
+		commitRange := git.CommitRange{Until: tags[i].Name}
+		if i > 0 {
+			commitRange.Since = tags[i-1].Name
+		}
+
+		commits, err := deps.CommitReader.GetCommits(ctx, commitRange)
+		if err != nil {
+			return fmt.Errorf("failed to get commits for %s: %w", tags[i].Name, err)
+		}
+
+		if len(commits) == 0 {
+			continue
+		}
+
+		releaseOpts := opts
+		releaseOpts.Since = commitRange.Since
+		releaseOpts.Until = commitRange.Until
+		releaseOpts.Version = ReleaseLabel(tags[i])
+		releaseOpts.Date = tags[i].Date
+		releaseOpts.Stream = false
+		if opts.Format == FormatKeepAChangelog || opts.Format == FormatJSON {
+			releaseOpts.Version = tags[i].Name
+		}
+
+		var release bytes.Buffer
+		if err := renderChangelog(ctx, deps, releaseOpts, commits, &release); err != nil {
+			return err
+		}
+		releases = append(releases, strings.TrimRight(release.String(), "\n")+"\n")
+		versions = append(versions, tags[i].Name)
+	}
+
+	switch opts.Format {
+	case FormatKeepAChangelog:
+		return writeKeepAChangelogHistory(releases, versions, writer)
+	case FormatJSON:
+		_, err = fmt.Fprint(writer, changelog.RenderJSONHistory(releases))
+		return err
+	}
+
+	_, err = fmt.Fprint(writer, strings.Join(releases, "\n"))
+	return err
+}
+
+func writeKeepAChangelogHistory(releases []string, versions []string, writer io.Writer) error {
+	document := ""
+	for i := len(releases) - 1; i >= 0; i-- {
+		var err error
+		document, err = changelog.PrependRelease(document, releases[i], versions[i], false)
+		if err != nil {
+			return fmt.Errorf("failed to assemble history: %w", err)
+		}
+	}
+
+	_, err := fmt.Fprint(writer, document)
+	return err
+}
+
+func ReleaseLabel(tag git.Tag) string {
+	return fmt.Sprintf("%s (%s)", tag.Name, tag.Date.UTC().Format("2006-01-02"))
+}
+
+func WriteHistoryToFile(ctx context.Context, deps GenerateDeps, opts GenerateOptions, path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+	defer file.Close()
+
+	return RunHistory(ctx, deps, opts, file)
+}
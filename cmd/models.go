@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/brognilucas/ai-changelog/internal/ollama"
+	"github.com/spf13/cobra"
+)
+
+type ModelLister interface {
+	ListModels(ctx context.Context) ([]ollama.Model, error)
+	ContextLength(ctx context.Context, model string) (int, error)
+}
+
+func NewModelsCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "models",
+		Short: "List the models available on the Ollama server and their context lengths",
+	}
+}
+
+func RunModels(ctx context.Context, lister ModelLister, writer io.Writer) error {
+	models, err := lister.ListModels(ctx)
+	if err != nil {
+		return err
+	}
+
+	if len(models) == 0 {
+		fmt.Fprintln(writer, "No models found. Pull one with: ollama pull llama3.2")
+		return nil
+	}
+
+	table := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "NAME\tPARAMETERS\tSIZE\tCONTEXT")
+
+	for _, model := range models {
+		contextLength := "-"
+		length, err := lister.ContextLength(ctx, model.Name)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		} else if length > 0 {
+			contextLength = strconv.Itoa(length)
+		}
+
+		fmt.Fprintf(table, "%s\t%s\t%s\t%s\n", model.Name, valueOrDash(model.Details.ParameterSize), formatSize(model.Size), contextLength)
+	}
+
+	return table.Flush()
+}
+
+func valueOrDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
+
+func formatSize(bytes int64) string {
+	const unit = 1000
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+
+	size := float64(bytes)
+	suffixes := []string{"KB", "MB", "GB", "TB"}
+	suffix := ""
+	for _, next := range suffixes {
+		size /= unit
+		suffix = next
+		if size < unit {
+			break
+		}
+	}
+	return fmt.Sprintf("%.1f %s", size, suffix)
+}
@@ -1,6 +1,11 @@
 package cmd
 
 import (
+	"time"
+
+	"github.com/brognilucas/ai-changelog/internal/config"
+	"github.com/brognilucas/ai-changelog/internal/llm"
+	"github.com/brognilucas/ai-changelog/internal/ollama"
 	"github.com/spf13/cobra"
 )
 
@@ -10,11 +15,53 @@ func NewRootCommand() *cobra.Command {
 		Short: "Generate changelogs from git commits using AI",
 	}
 
+	rootCmd.PersistentFlags().String("config", "", "JSON file with flag defaults keyed by flag name (defaults to "+config.DefaultFile+" when present)")
 	rootCmd.PersistentFlags().StringP("output", "o", "", "write changelog to file instead of stdout")
-	rootCmd.PersistentFlags().StringP("since", "s", "", "generate changelog since tag or date")
-	rootCmd.PersistentFlags().StringP("model", "m", "llama3.2", "ollama model to use for summarization")
-	rootCmd.PersistentFlags().StringP("format", "f", "markdown", "output format: markdown or plain")
+	rootCmd.PersistentFlags().StringP("since", "s", "", "generate changelog since a tag, date (2024-01-01) or revision range (v1.2.0..v1.3.0), or \"auto\" for the latest reachable tag")
+	rootCmd.PersistentFlags().StringP("until", "u", "", "end the changelog at a tag, branch or date (defaults to HEAD)")
+	rootCmd.PersistentFlags().StringP("model", "m", "llama3.2", "model to use for summarization")
+	rootCmd.PersistentFlags().StringP("format", "f", "markdown", "output format: markdown, plain, keepachangelog or json")
 	rootCmd.PersistentFlags().StringP("version", "V", "", "version label for the changelog header (e.g., v1.2.0)")
+	rootCmd.PersistentFlags().Bool("prepend", false, "insert the new version into the existing --output file instead of overwriting it (requires --version)")
+	rootCmd.PersistentFlags().Bool("force", false, "with --prepend, replace the version if it is already in the file")
+	rootCmd.PersistentFlags().String("tag-pattern", "", "glob used by --since auto to select tags (e.g., v*)")
+	rootCmd.PersistentFlags().Bool("skip-prerelease", false, "ignore pre-release tags (e.g., v1.2.0-rc.1) when using --since auto")
+	rootCmd.PersistentFlags().String("repo-url", "", "repository URL for keepachangelog compare links (defaults to the origin remote)")
+	rootCmd.PersistentFlags().String("provider", "ollama", "LLM provider: ollama or openai (any OpenAI-compatible server such as vLLM, llama.cpp or LM Studio)")
+	rootCmd.PersistentFlags().String("endpoint", "", "LLM server URL (defaults to http://localhost:11434 for ollama, http://localhost:8000/v1 for openai)")
+	rootCmd.PersistentFlags().Duration("timeout", 0, "overall time limit for the run, e.g. 5m (0 means no limit)")
+	rootCmd.PersistentFlags().Duration("llm-timeout", llm.DefaultTimeout, "time limit for each LLM request")
+	rootCmd.PersistentFlags().Int("retries", 3, "retries for failed LLM requests (connection errors, 429, 5xx and 503 while the model loads)")
+	rootCmd.PersistentFlags().Duration("retry-delay", time.Second, "initial delay between retries, doubled on each attempt with jitter")
+	rootCmd.PersistentFlags().Bool("pull", false, "pull the model through Ollama when it is not available on the server")
+	rootCmd.PersistentFlags().Bool("warm-up", false, "load the model into memory before generating")
+	rootCmd.PersistentFlags().String("keep-alive", "", "how long Ollama keeps the model loaded after a request (e.g., 10m)")
+	rootCmd.PersistentFlags().Float64("temperature", llm.DefaultTemperature, "sampling temperature; low values keep the notes stable between runs")
+	rootCmd.PersistentFlags().Int("seed", llm.DefaultSeed, "random seed for sampling, so the same commits produce the same notes")
+	rootCmd.PersistentFlags().Float64("top-p", llm.DefaultTopP, "nucleus sampling threshold")
+	rootCmd.PersistentFlags().Int("num-ctx", 0, "context window Ollama allocates for the model (defaults to --context-length)")
+	rootCmd.PersistentFlags().Int("num-predict", 0, "maximum tokens to generate per request (0 means the server default)")
+	rootCmd.PersistentFlags().String("prompt-template", "", "Go text/template file used to build the changelog prompt instead of the built-in one")
+	rootCmd.PersistentFlags().String("llm-output", "markdown", "what the LLM is asked for: markdown (printed as is) or json (validated entries rendered in any --format)")
+	rootCmd.PersistentFlags().StringSlice("sections", nil, "sections the LLM may use with --llm-output json (defaults to the sections of --format)")
+	rootCmd.PersistentFlags().String("uncited", UncitedDrop, "LLM entries that cite no commit in the range: drop, or flag to keep them marked as unverified")
+	rootCmd.PersistentFlags().Bool("strict", false, "fall back to structured output when the LLM changelog covers less than --min-coverage of the commits")
+	rootCmd.PersistentFlags().Float64("min-coverage", DefaultMinCoverage, "share of user-facing commits the LLM changelog must cite with --strict")
+	rootCmd.PersistentFlags().StringArray("redact", nil, "extra regular expression (repeatable) masked before commits are sent to the LLM (emails, API keys, internal hosts and ticket URLs are always masked)")
+	rootCmd.PersistentFlags().Bool("no-redact", false, "send commits to the LLM without masking secrets and personal data")
+	rootCmd.PersistentFlags().Bool("verbose", false, "report what was redacted from the commits sent to the LLM")
+	rootCmd.PersistentFlags().Bool("no-cache", false, "always ask the LLM instead of reusing a cached response for the same commits, model, prompt and options")
+	rootCmd.PersistentFlags().Bool("no-stream", false, "request the complete LLM response at once instead of streaming it with a progress line on stderr")
+	rootCmd.PersistentFlags().Int("context-length", 4096, "model context window in tokens; larger commit ranges are summarised in chunks and merged")
+	rootCmd.PersistentFlags().String("ollama-url", "", "Ollama server URL or host:port (defaults to $OLLAMA_HOST, then http://localhost:11434)")
+	rootCmd.PersistentFlags().String("ollama-token", "", "bearer token sent to the Ollama server (for instances behind a reverse proxy)")
+	rootCmd.PersistentFlags().String("ollama-basic-auth", "", "user:password sent as basic auth to the Ollama server")
+	rootCmd.PersistentFlags().String("ollama-ca-cert", "", "PEM CA bundle used to verify the Ollama server certificate")
+	rootCmd.PersistentFlags().String("examples-from", "", "existing changelog whose latest releases, with their commits, are shown to the model as examples (chat API only)")
+	rootCmd.PersistentFlags().Int("examples", DefaultMaxExamples, "number of releases from --examples-from used as examples")
+	rootCmd.PersistentFlags().StringArray("refine", nil, "follow-up instruction (repeatable) the model applies to its changelog, e.g. \"make it shorter\" (chat API only)")
+	rootCmd.PersistentFlags().String("ollama-api", ollama.APIChat, "Ollama API used for changelogs: chat (rules sent as a system message) or generate (one prompt, for older models)")
+	rootCmd.PersistentFlags().String("group-by", "category", "structured output grouping: category or scope (nests entries by scope inside each category)")
 
 	return rootCmd
 }
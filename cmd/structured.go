@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/brognilucas/ai-changelog/internal/changelog"
+	"github.com/brognilucas/ai-changelog/internal/git"
+	"github.com/brognilucas/ai-changelog/internal/llm"
+)
+
+// renderStructuredChangelog renders the LLM's JSON entries with the regular renderers, so
+// every output format works in LLM mode. Output that fails validation is never printed; the
+// commit-based changelog is rendered instead.
+func renderStructuredChangelog(ctx context.Context, deps GenerateDeps, opts GenerateOptions, commits []git.Commit, writer io.Writer) error {
+	response, ok := cachedChangelog(deps, opts, commits)
+	if !ok {
+		var err error
+		response, err = generateWithLLM(ctx, deps, opts, commits)
+		if err != nil {
+			return err
+		}
+	}
+
+	renderer := newRenderer(opts)
+	sections := groupCommits(opts, changelog.SortByDate(commits))
+
+	if response != "" {
+		if structured, ok := parseStructured(opts, response); ok {
+			entries, verification := changelog.VerifyEntries(changelogEntries(structured.Entries), commits, opts.Uncited == UncitedFlag)
+			if acceptVerification(opts, verification) {
+				if jsonRenderer, ok := renderer.(*changelog.JSONRenderer); ok {
+					jsonRenderer.Mode = changelog.ModeLLM
+					jsonRenderer.Model = opts.Model
+					jsonRenderer.LLMText = response
+					jsonRenderer.LLMCommits = commitHashes(commits)
+					jsonRenderer.LLMOptions = jsonModelOptions(opts.ModelOptions)
+					jsonRenderer.LLMSummary = structured.Summary
+					jsonRenderer.LLMEntries = entries
+					jsonRenderer.LLMVerification = &verification
+				} else {
+					setSummary(renderer, structured.Summary)
+					sections = changelog.GroupEntries(entries, llmSections(opts))
+				}
+			}
+		}
+	}
+
+	_, err := fmt.Fprint(writer, renderer.Render(sections, opts.Version))
+	return err
+}
+
+func parseStructured(opts GenerateOptions, response string) (llm.StructuredChangelog, bool) {
+	structured, err := llm.ParseStructuredChangelog(response, llmSections(opts))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v, falling back to structured output\n", err)
+		return structured, false
+	}
+	return structured, true
+}
+
+func changelogEntries(entries []llm.StructuredEntry) []changelog.Entry {
+	converted := make([]changelog.Entry, 0, len(entries))
+	for _, entry := range entries {
+		converted = append(converted, changelog.Entry{Section: entry.Section, Text: entry.Text, Commits: entry.Commits})
+	}
+	return converted
+}
+
+func setSummary(renderer changelog.Renderer, summary string) {
+	switch r := renderer.(type) {
+	case *changelog.MarkdownRenderer:
+		r.Summary = summary
+	case *changelog.PlainTextRenderer:
+		r.Summary = summary
+	case *changelog.KeepAChangelogRenderer:
+		r.Summary = summary
+	}
+}
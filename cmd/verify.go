@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/brognilucas/ai-changelog/internal/changelog"
+	"github.com/brognilucas/ai-changelog/internal/git"
+)
+
+// Entries whose citations name no commit in the range are dropped, or kept and marked as
+// unverified with UncitedFlag.
+const (
+	UncitedDrop = "drop"
+	UncitedFlag = "flag"
+)
+
+const DefaultMinCoverage = 0.8
+
+// acceptVerification reports uncited entries and uncovered commits on stderr, and reports
+// whether the verified changelog may be used. --strict rejects it below --min-coverage.
+func acceptVerification(opts GenerateOptions, verification changelog.Verification) bool {
+	if !verification.Cited {
+		// The built-in template asks for citations, so a changelog without any cannot be trusted.
+		if opts.Strict || opts.PromptTemplate == nil {
+			fmt.Fprintln(os.Stderr, "Warning: the changelog cites no commits and cannot be verified, falling back to structured output")
+			return false
+		}
+		fmt.Fprintln(os.Stderr, "Warning: the changelog cites no commits and cannot be verified")
+		return true
+	}
+
+	dropped := opts.Uncited != UncitedFlag
+	reportVerification(verification, dropped)
+
+	if dropped && len(verification.Covered) == 0 {
+		fmt.Fprintln(os.Stderr, "Warning: no entry cites a commit in the range, falling back to structured output")
+		return false
+	}
+
+	if opts.Strict && verification.Coverage() < opts.MinCoverage {
+		fmt.Fprintf(os.Stderr, "Warning: coverage %.0f%% is below --min-coverage %.0f%%, falling back to structured output\n", verification.Coverage()*100, opts.MinCoverage*100)
+		return false
+	}
+
+	return true
+}
+
+func reportVerification(verification changelog.Verification, dropped bool) {
+	for _, entry := range verification.Uncited {
+		if dropped {
+			fmt.Fprintf(os.Stderr, "Warning: dropped entry that cites no commit in the range: %s\n", entry)
+		} else {
+			fmt.Fprintf(os.Stderr, "Warning: entry cites no commit in the range: %s\n", entry)
+		}
+	}
+
+	if len(verification.Uncovered) == 0 {
+		return
+	}
+
+	fmt.Fprintf(os.Stderr, "Warning: %d of %d commits are not covered by the changelog (%.0f%% coverage):\n", len(verification.Uncovered), verification.Total(), verification.Coverage()*100)
+	for _, commit := range verification.Uncovered {
+		fmt.Fprintf(os.Stderr, "  %s %s\n", git.ShortHash(commit.Hash), commit.Subject)
+	}
+}
@@ -0,0 +1,46 @@
+package changelog
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Entry is a changelog line written by the LLM, with the short hashes of the commits it
+// summarises.
+type Entry struct {
+	Section string
+	Text    string
+	Commits []string
+}
+
+// GroupEntries puts LLM entries into sections, in the given section order.
+func GroupEntries(entries []Entry, order []string) []ChangelogSection {
+	grouped := make(map[string][]Entry)
+	for _, entry := range entries {
+		grouped[entry.Section] = append(grouped[entry.Section], entry)
+	}
+
+	sections := []ChangelogSection{}
+	for _, title := range order {
+		if sectionEntries, ok := grouped[title]; ok {
+			sections = append(sections, ChangelogSection{Title: title, Entries: sectionEntries})
+		}
+	}
+
+	return sections
+}
+
+func KeepAChangelogSections() []string {
+	return append([]string(nil), keepAChangelogOrder...)
+}
+
+func isEmptySection(section ChangelogSection) bool {
+	return len(section.Commits) == 0 && len(section.Entries) == 0
+}
+
+func renderEntryLine(entry Entry, bullet string) string {
+	if len(entry.Commits) == 0 {
+		return fmt.Sprintf("%s %s\n", bullet, entry.Text)
+	}
+	return fmt.Sprintf("%s %s (%s)\n", bullet, entry.Text, strings.Join(entry.Commits, ", "))
+}
@@ -2,11 +2,13 @@ package changelog
 
 import (
 	"sort"
+	"strings"
 
 	"github.com/brognilucas/ai-changelog/internal/git"
 )
 
 const (
+	CategoryBreaking = "breaking"
 	CategoryFeat     = "feat"
 	CategoryFix      = "fix"
 	CategoryPerf     = "perf"
@@ -19,6 +21,7 @@ const (
 )
 
 var categoryDisplayNames = map[string]string{
+	CategoryBreaking: "Breaking Changes",
 	CategoryFeat:     "New Features",
 	CategoryFix:      "Bug Fixes",
 	CategoryPerf:     "Performance",
@@ -38,6 +41,7 @@ func GetDisplayName(prefix string) string {
 }
 
 var categoryOrder = []string{
+	CategoryBreaking,
 	CategoryFeat,
 	CategoryFix,
 	CategoryPerf,
@@ -52,6 +56,13 @@ var categoryOrder = []string{
 type ChangelogSection struct {
 	Title   string
 	Commits []git.Commit
+	Scopes  []ScopeGroup
+	Entries []Entry
+}
+
+type ScopeGroup struct {
+	Scope   string
+	Commits []git.Commit
 }
 
 func GroupByCategory(commits []git.Commit) []ChangelogSection {
@@ -61,7 +72,11 @@ func GroupByCategory(commits []git.Commit) []ChangelogSection {
 
 	grouped := make(map[string][]git.Commit)
 	for _, commit := range commits {
-		grouped[commit.Prefix] = append(grouped[commit.Prefix], commit)
+		category := commit.Prefix
+		if commit.Breaking {
+			category = CategoryBreaking
+		}
+		grouped[category] = append(grouped[category], commit)
 	}
 
 	var sections []ChangelogSection
@@ -77,6 +92,119 @@ func GroupByCategory(commits []git.Commit) []ChangelogSection {
 	return sections
 }
 
+func GroupByCategoryAndScope(commits []git.Commit) []ChangelogSection {
+	sections := GroupByCategory(commits)
+	for i := range sections {
+		sections[i].Scopes = GroupByScope(sections[i].Commits)
+	}
+	return sections
+}
+
+func GroupByScope(commits []git.Commit) []ScopeGroup {
+	if len(commits) == 0 {
+		return []ScopeGroup{}
+	}
+
+	grouped := make(map[string][]git.Commit)
+	var scopes []string
+	for _, commit := range commits {
+		if _, ok := grouped[commit.Scope]; !ok {
+			scopes = append(scopes, commit.Scope)
+		}
+		grouped[commit.Scope] = append(grouped[commit.Scope], commit)
+	}
+
+	// Unscoped commits come first, followed by scopes in alphabetical order.
+	sort.Strings(scopes)
+
+	groups := make([]ScopeGroup, 0, len(scopes))
+	for _, scope := range scopes {
+		groups = append(groups, ScopeGroup{
+			Scope:   scope,
+			Commits: grouped[scope],
+		})
+	}
+
+	return groups
+}
+
+const (
+	KeepAChangelogAdded      = "Added"
+	KeepAChangelogChanged    = "Changed"
+	KeepAChangelogDeprecated = "Deprecated"
+	KeepAChangelogRemoved    = "Removed"
+	KeepAChangelogFixed      = "Fixed"
+	KeepAChangelogSecurity   = "Security"
+)
+
+var keepAChangelogOrder = []string{
+	KeepAChangelogAdded,
+	KeepAChangelogChanged,
+	KeepAChangelogDeprecated,
+	KeepAChangelogRemoved,
+	KeepAChangelogFixed,
+	KeepAChangelogSecurity,
+}
+
+var keepAChangelogCategories = map[string]string{
+	CategoryFeat:     KeepAChangelogAdded,
+	CategoryFix:      KeepAChangelogFixed,
+	CategoryPerf:     KeepAChangelogChanged,
+	CategoryRefactor: KeepAChangelogChanged,
+	CategoryOther:    KeepAChangelogChanged,
+}
+
+func KeepAChangelogType(commit git.Commit) string {
+	if commit.Scope == "security" {
+		return KeepAChangelogSecurity
+	}
+
+	base := keepAChangelogCategories[commit.Prefix]
+	if base == "" && !commit.Breaking {
+		return ""
+	}
+
+	subject := strings.ToLower(commit.Subject)
+
+	switch {
+	case strings.Contains(subject, "security") || strings.Contains(subject, "vulnerab"):
+		return KeepAChangelogSecurity
+	case strings.Contains(subject, "deprecat"):
+		return KeepAChangelogDeprecated
+	case commit.Breaking && (strings.Contains(subject, "remove") || strings.Contains(subject, "drop")):
+		return KeepAChangelogRemoved
+	case commit.Breaking:
+		return KeepAChangelogChanged
+	}
+
+	return base
+}
+
+func GroupByKeepAChangelog(commits []git.Commit) []ChangelogSection {
+	if len(commits) == 0 {
+		return []ChangelogSection{}
+	}
+
+	grouped := make(map[string][]git.Commit)
+	for _, commit := range commits {
+		if section := KeepAChangelogType(commit); section != "" {
+			grouped[section] = append(grouped[section], commit)
+		}
+	}
+
+	var sections []ChangelogSection
+	for _, section := range keepAChangelogOrder {
+		if commitList, ok := grouped[section]; ok && len(commitList) > 0 {
+			sections = append(sections, ChangelogSection{
+				Title:   section,
+				Commits: commitList,
+			})
+		}
+	}
+
+	return sections
+}
+
 func SortByDate(commits []git.Commit) []git.Commit {
 	if commits == nil {
 		return nil
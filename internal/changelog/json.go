@@ -0,0 +1,201 @@
+package changelog
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/brognilucas/ai-changelog/internal/git"
+)
+
+const JSONSchemaVersion = 1
+
+const (
+	ModeLLM      = "llm"
+	ModeFallback = "fallback"
+)
+
+type JSONDocument struct {
+	SchemaVersion int            `json:"schema_version"`
+	Version       string         `json:"version"`
+	Date          string         `json:"date,omitempty"`
+	Range         JSONRange      `json:"range"`
+	Mode          string         `json:"mode"`
+	Model         string         `json:"model,omitempty"`
+	Sections      []JSONSection  `json:"sections"`
+	LLM           *JSONLLMOutput `json:"llm,omitempty"`
+}
+
+type JSONRange struct {
+	Since string `json:"since"`
+	Until string `json:"until"`
+}
+
+type JSONSection struct {
+	Title   string      `json:"title"`
+	Entries []JSONEntry `json:"entries"`
+}
+
+type JSONEntry struct {
+	Subject        string `json:"subject"`
+	CleanedSubject string `json:"cleaned_subject"`
+	Hash           string `json:"hash"`
+	Author         string `json:"author"`
+	Timestamp      string `json:"timestamp"`
+	Category       string `json:"category"`
+	Scope          string `json:"scope"`
+	Breaking       bool   `json:"breaking"`
+}
+
+type JSONLLMOutput struct {
+	Text     string            `json:"text"`
+	Commits  []string          `json:"commits"`
+	Summary  string            `json:"summary,omitempty"`
+	Entries  []JSONLLMEntry    `json:"entries,omitempty"`
+	Coverage *JSONCoverage     `json:"coverage,omitempty"`
+	Options  *JSONModelOptions `json:"options,omitempty"`
+}
+
+// JSONCoverage reports how well the LLM entries are backed by the commits of the release.
+type JSONCoverage struct {
+	Ratio     float64  `json:"ratio"`
+	Covered   int      `json:"covered"`
+	Total     int      `json:"total"`
+	Uncovered []string `json:"uncovered"`
+	Uncited   []string `json:"uncited"`
+}
+
+// JSONLLMEntry is one entry of a structured LLM changelog.
+type JSONLLMEntry struct {
+	Section string   `json:"section"`
+	Text    string   `json:"text"`
+	Commits []string `json:"commits"`
+}
+
+// JSONModelOptions records the sampling options of an LLM run so it can be reproduced.
+type JSONModelOptions struct {
+	Temperature float64 `json:"temperature"`
+	Seed        int     `json:"seed"`
+	TopP        float64 `json:"top_p"`
+	NumCtx      int     `json:"num_ctx,omitempty"`
+	NumPredict  int     `json:"num_predict,omitempty"`
+}
+
+type JSONHistory struct {
+	SchemaVersion int               `json:"schema_version"`
+	Releases      []json.RawMessage `json:"releases"`
+}
+
+type JSONRenderer struct {
+	Date       time.Time
+	Since      string
+	Until      string
+	Mode       string
+	Model      string
+	LLMText    string
+	LLMCommits []string
+	LLMOptions *JSONModelOptions
+	LLMSummary string
+	LLMEntries []Entry
+
+	LLMVerification *Verification
+}
+
+func (r *JSONRenderer) Render(sections []ChangelogSection, version string) string {
+	document := JSONDocument{
+		SchemaVersion: JSONSchemaVersion,
+		Version:       version,
+		Range:         JSONRange{Since: r.Since, Until: r.Until},
+		Mode:          r.Mode,
+		Model:         r.Model,
+		Sections:      []JSONSection{},
+	}
+
+	if document.Mode == "" {
+		document.Mode = ModeFallback
+	}
+
+	if !r.Date.IsZero() {
+		document.Date = r.Date.Format("2006-01-02")
+	}
+
+	for _, section := range sections {
+		if len(section.Commits) == 0 {
+			continue
+		}
+		document.Sections = append(document.Sections, newJSONSection(section))
+	}
+
+	if document.Mode == ModeLLM {
+		document.LLM = &JSONLLMOutput{Text: r.LLMText, Commits: r.LLMCommits, Summary: r.LLMSummary, Options: r.LLMOptions}
+		for _, entry := range r.LLMEntries {
+			document.LLM.Entries = append(document.LLM.Entries, JSONLLMEntry{Section: entry.Section, Text: entry.Text, Commits: entry.Commits})
+		}
+		if verification := r.LLMVerification; verification != nil && verification.Cited {
+			document.LLM.Coverage = newJSONCoverage(*verification)
+		}
+	}
+
+	return marshalJSON(document)
+}
+
+func newJSONCoverage(verification Verification) *JSONCoverage {
+	coverage := &JSONCoverage{
+		Ratio:     verification.Coverage(),
+		Covered:   len(verification.Covered),
+		Total:     verification.Total(),
+		Uncovered: []string{},
+		Uncited:   []string{},
+	}
+	for _, commit := range verification.Uncovered {
+		coverage.Uncovered = append(coverage.Uncovered, commit.Hash)
+	}
+	coverage.Uncited = append(coverage.Uncited, verification.Uncited...)
+	return coverage
+}
+
+func RenderJSONHistory(releases []string) string {
+	history := JSONHistory{
+		SchemaVersion: JSONSchemaVersion,
+		Releases:      make([]json.RawMessage, 0, len(releases)),
+	}
+
+	for _, release := range releases {
+		history.Releases = append(history.Releases, json.RawMessage(release))
+	}
+
+	return marshalJSON(history)
+}
+
+func newJSONSection(section ChangelogSection) JSONSection {
+	jsonSection := JSONSection{
+		Title:   section.Title,
+		Entries: make([]JSONEntry, 0, len(section.Commits)),
+	}
+
+	for _, commit := range section.Commits {
+		jsonSection.Entries = append(jsonSection.Entries, newJSONEntry(commit))
+	}
+
+	return jsonSection
+}
+
+func newJSONEntry(commit git.Commit) JSONEntry {
+	return JSONEntry{
+		Subject:        commit.Subject,
+		CleanedSubject: cleanSubject(commit.Subject),
+		Hash:           commit.Hash,
+		Author:         commit.Author,
+		Timestamp:      commit.Timestamp.UTC().Format(time.RFC3339),
+		Category:       commit.Prefix,
+		Scope:          commit.Scope,
+		Breaking:       commit.Breaking,
+	}
+}
+
+func marshalJSON(value any) string {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return ""
+	}
+	return string(data) + "\n"
+}
@@ -0,0 +1,111 @@
+package changelog
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/brognilucas/ai-changelog/internal/git"
+)
+
+const keepAChangelogPreamble = `# Changelog
+
+All notable changes to this project will be documented in this file.
+
+The format is based on [Keep a Changelog](https://keepachangelog.com/en/1.1.0/),
+and this project adheres to [Semantic Versioning](https://semver.org/spec/v2.0.0.html).
+`
+
+type KeepAChangelogRenderer struct {
+	Date            time.Time
+	PreviousVersion string
+	RepositoryURL   string
+	Summary         string
+}
+
+func (r *KeepAChangelogRenderer) Render(sections []ChangelogSection, version string) string {
+	var builder strings.Builder
+
+	builder.WriteString(keepAChangelogPreamble)
+	builder.WriteString("\n")
+	builder.WriteString(r.renderReleaseHeader(version))
+
+	if r.Summary != "" {
+		builder.WriteString("\n" + r.Summary + "\n")
+	}
+
+	for _, section := range sections {
+		if isEmptySection(section) {
+			continue
+		}
+		builder.WriteString("\n")
+		builder.WriteString(renderKeepAChangelogSection(section))
+	}
+
+	if link := r.renderCompareLink(version); link != "" {
+		builder.WriteString("\n")
+		builder.WriteString(link)
+	}
+
+	return builder.String()
+}
+
+func (r *KeepAChangelogRenderer) renderReleaseHeader(version string) string {
+	if version == "" {
+		return "## [Unreleased]\n"
+	}
+
+	date := r.Date
+	if date.IsZero() {
+		date = time.Now()
+	}
+	return fmt.Sprintf("## [%s] - %s\n", keepAChangelogLabel(version), date.Format("2006-01-02"))
+}
+
+func (r *KeepAChangelogRenderer) renderCompareLink(version string) string {
+	if r.RepositoryURL == "" {
+		return ""
+	}
+
+	repositoryURL := strings.TrimSuffix(r.RepositoryURL, "/")
+
+	if version == "" {
+		if r.PreviousVersion == "" {
+			return ""
+		}
+		return fmt.Sprintf("[unreleased]: %s/compare/%s...HEAD\n", repositoryURL, r.PreviousVersion)
+	}
+
+	if r.PreviousVersion == "" {
+		return fmt.Sprintf("[%s]: %s/releases/tag/%s\n", keepAChangelogLabel(version), repositoryURL, version)
+	}
+	return fmt.Sprintf("[%s]: %s/compare/%s...%s\n", keepAChangelogLabel(version), repositoryURL, r.PreviousVersion, version)
+}
+
+func keepAChangelogLabel(version string) string {
+	return strings.TrimPrefix(version, "v")
+}
+
+func renderKeepAChangelogSection(section ChangelogSection) string {
+	var builder strings.Builder
+
+	builder.WriteString(fmt.Sprintf("### %s\n\n", section.Title))
+
+	for _, entry := range section.Entries {
+		builder.WriteString(renderEntryLine(entry, "-"))
+	}
+
+	for _, commit := range section.Commits {
+		builder.WriteString(renderKeepAChangelogLine(commit))
+	}
+
+	return builder.String()
+}
+
+func renderKeepAChangelogLine(commit git.Commit) string {
+	prefix := markdownScopePrefix(commit.Scope)
+	if commit.Breaking {
+		prefix = "**BREAKING:** " + prefix
+	}
+	return renderMarkdownCommitLine(commit, prefix)
+}
@@ -0,0 +1,53 @@
+package changelog
+
+import (
+	"regexp"
+	"strings"
+)
+
+var releaseVersionPattern = regexp.MustCompile(`v?\d+\.\d+(?:\.\d+)?(?:-[0-9A-Za-z.-]+)?`)
+
+// Release is one version section of an existing changelog.
+type Release struct {
+	Version string
+	Body    string
+}
+
+// ParseReleases returns the version sections of a changelog in file order, usually newest
+// first. Unreleased sections, headings without a version and link references are left out.
+func ParseReleases(text string) []Release {
+	lines := parseMarkdownLines(text)
+	level := releaseLevel(lines)
+
+	var releases []Release
+	var current *Release
+	var body []string
+
+	flush := func() {
+		if current != nil {
+			current.Body = strings.TrimSpace(strings.Join(body, "\n"))
+			if current.Body != "" {
+				releases = append(releases, *current)
+			}
+		}
+		current, body = nil, nil
+	}
+
+	for _, line := range lines {
+		if line.level > 0 && line.level <= level {
+			flush()
+			if line.level == level && !unreleasedPattern.MatchString(line.text) {
+				if version := releaseVersionPattern.FindString(line.text); version != "" {
+					current = &Release{Version: version}
+				}
+			}
+			continue
+		}
+		if current != nil && !linkReferencePattern.MatchString(line.text) {
+			body = append(body, line.text)
+		}
+	}
+	flush()
+
+	return releases
+}
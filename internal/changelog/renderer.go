@@ -11,15 +11,21 @@ type Renderer interface {
 	Render(sections []ChangelogSection, version string) string
 }
 
-type MarkdownRenderer struct{}
+type MarkdownRenderer struct {
+	Summary string
+}
 
 func (r *MarkdownRenderer) Render(sections []ChangelogSection, version string) string {
 	var builder strings.Builder
 
 	builder.WriteString(renderMarkdownVersionHeader(version))
 
+	if r.Summary != "" {
+		builder.WriteString(fmt.Sprintf("\n_%s_\n", r.Summary))
+	}
+
 	for _, section := range sections {
-		if len(section.Commits) == 0 {
+		if isEmptySection(section) {
 			continue
 		}
 		builder.WriteString("\n")
@@ -41,33 +47,65 @@ func renderMarkdownSection(section ChangelogSection) string {
 
 	builder.WriteString(fmt.Sprintf("## %s\n\n", section.Title))
 
-	for _, commit := range section.Commits {
-		builder.WriteString(renderMarkdownCommitLine(commit))
+	for _, entry := range section.Entries {
+		builder.WriteString(renderEntryLine(entry, "-"))
+	}
+
+	if len(section.Scopes) == 0 {
+		for _, commit := range section.Commits {
+			builder.WriteString(renderMarkdownCommitLine(commit, markdownScopePrefix(commit.Scope)))
+		}
+		return builder.String()
+	}
+
+	for i, group := range section.Scopes {
+		if group.Scope != "" {
+			if i > 0 {
+				builder.WriteString("\n")
+			}
+			builder.WriteString(fmt.Sprintf("### %s\n\n", group.Scope))
+		}
+		for _, commit := range group.Commits {
+			builder.WriteString(renderMarkdownCommitLine(commit, ""))
+		}
 	}
 
 	return builder.String()
 }
 
-func renderMarkdownCommitLine(commit git.Commit) string {
-	return fmt.Sprintf("- %s (%s)\n", cleanSubject(commit.Subject), shortHash(commit.Hash))
+func renderMarkdownCommitLine(commit git.Commit, scopePrefix string) string {
+	return fmt.Sprintf("- %s%s (%s)%s\n", scopePrefix, cleanSubject(commit.Subject), git.ShortHash(commit.Hash), breakingSuffix(commit))
+}
+
+func markdownScopePrefix(scope string) string {
+	if scope == "" {
+		return ""
+	}
+	return fmt.Sprintf("**%s:** ", scope)
 }
 
-func shortHash(hash string) string {
-	if len(hash) > 7 {
-		return hash[:7]
+func breakingSuffix(commit git.Commit) string {
+	if !commit.Breaking || commit.BreakingDescription == "" || commit.BreakingDescription == cleanSubject(commit.Subject) {
+		return ""
 	}
-	return hash
+	return " — " + commit.BreakingDescription
 }
 
-type PlainTextRenderer struct{}
+type PlainTextRenderer struct {
+	Summary string
+}
 
 func (r *PlainTextRenderer) Render(sections []ChangelogSection, version string) string {
 	var builder strings.Builder
 
 	builder.WriteString(renderPlainTextVersionHeader(version))
 
+	if r.Summary != "" {
+		builder.WriteString("\n" + r.Summary + "\n")
+	}
+
 	for _, section := range sections {
-		if len(section.Commits) == 0 {
+		if isEmptySection(section) {
 			continue
 		}
 		builder.WriteString("\n")
@@ -92,15 +130,40 @@ func renderPlainTextSection(section ChangelogSection) string {
 
 	builder.WriteString(strings.ToUpper(section.Title) + "\n\n")
 
-	for _, commit := range section.Commits {
-		builder.WriteString(renderPlainTextCommitLine(commit))
+	for _, entry := range section.Entries {
+		builder.WriteString(renderEntryLine(entry, "  *"))
+	}
+
+	if len(section.Scopes) == 0 {
+		for _, commit := range section.Commits {
+			builder.WriteString(renderPlainTextCommitLine(commit, "  ", plainTextScopePrefix(commit.Scope)))
+		}
+		return builder.String()
+	}
+
+	for _, group := range section.Scopes {
+		indent := "  "
+		if group.Scope != "" {
+			builder.WriteString(fmt.Sprintf("  %s:\n", group.Scope))
+			indent = "    "
+		}
+		for _, commit := range group.Commits {
+			builder.WriteString(renderPlainTextCommitLine(commit, indent, ""))
+		}
 	}
 
 	return builder.String()
 }
 
-func renderPlainTextCommitLine(commit git.Commit) string {
-	return fmt.Sprintf("  * %s (%s)\n", cleanSubject(commit.Subject), shortHash(commit.Hash))
+func renderPlainTextCommitLine(commit git.Commit, indent string, scopePrefix string) string {
+	return fmt.Sprintf("%s* %s%s (%s)%s\n", indent, scopePrefix, cleanSubject(commit.Subject), git.ShortHash(commit.Hash), breakingSuffix(commit))
+}
+
+func plainTextScopePrefix(scope string) string {
+	if scope == "" {
+		return ""
+	}
+	return scope + ": "
 }
 
 func cleanSubject(subject string) string {
@@ -0,0 +1,61 @@
+package changelog
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidStructure is returned for an LLM changelog that does not follow the sections the
+// prompt asked for, which is what a commit message taking over the prompt usually produces.
+var ErrInvalidStructure = errors.New("LLM changelog does not follow the required sections")
+
+// CheckStructure checks that a Markdown changelog only uses the given "## " sections and has
+// nothing but entries under them. Text before the first section is allowed for the summary.
+// An empty sections list disables the check.
+func CheckStructure(text string, sections []string) error {
+	if len(sections) == 0 {
+		return nil
+	}
+
+	allowed := make(map[string]bool, len(sections))
+	for _, section := range sections {
+		allowed[strings.ToLower(section)] = true
+	}
+
+	inSection := false
+	for _, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case trimmed == "":
+		case strings.HasPrefix(trimmed, "```"):
+			return fmt.Errorf("%w: output is wrapped in a code block", ErrInvalidStructure)
+		case strings.HasPrefix(trimmed, "## "):
+			heading := strings.TrimSpace(strings.TrimPrefix(trimmed, "## "))
+			if !allowed[strings.ToLower(heading)] {
+				return fmt.Errorf("%w: unexpected section %q", ErrInvalidStructure, heading)
+			}
+			inSection = true
+		case strings.HasPrefix(trimmed, "#"):
+			// Titles and sub-headings (e.g. a scope) do not change the sections.
+		case isEntryLine(trimmed):
+			if !inSection {
+				return fmt.Errorf("%w: entry %q is not under a section", ErrInvalidStructure, trimmed)
+			}
+		case inSection && line != trimmed:
+			// An indented line continues the entry above it.
+		case inSection:
+			return fmt.Errorf("%w: unexpected text %q", ErrInvalidStructure, trimmed)
+		}
+	}
+
+	if !inSection {
+		return fmt.Errorf("%w: no sections found", ErrInvalidStructure)
+	}
+	return nil
+}
+
+func isEntryLine(line string) bool {
+	return strings.HasPrefix(line, "- ") || strings.HasPrefix(line, "* ")
+}
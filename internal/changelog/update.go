@@ -0,0 +1,338 @@
+package changelog
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var ErrVersionExists = errors.New("version already present in changelog")
+
+var (
+	releaseHeadingPattern = regexp.MustCompile(`(?i)(^|[^\w.])v?\d+\.\d+(\.\d+)?|unreleased`)
+	unreleasedPattern     = regexp.MustCompile(`(?i)\bunreleased\b`)
+	linkReferencePattern  = regexp.MustCompile(`^\[[^\]]+\]:\s`)
+	unreleasedLinkPattern = regexp.MustCompile(`^(\[[^\]]+\]:\s+\S*/compare/)\S+?(\.\.\.HEAD\S*)$`)
+)
+
+type markdownLine struct {
+	text   string
+	offset int
+	level  int
+}
+
+func PrependRelease(existing string, release string, version string, force bool) (string, error) {
+	if version == "" {
+		return "", errors.New("a version is required to update an existing changelog")
+	}
+
+	versionPattern := versionHeadingPattern(version)
+
+	releaseLines := parseMarkdownLines(release)
+	releaseStart := -1
+	for i, line := range releaseLines {
+		if line.level > 0 && versionPattern.MatchString(line.text) {
+			releaseStart = i
+			break
+		}
+	}
+	if releaseStart == -1 {
+		return "", fmt.Errorf("generated changelog has no heading for version %s", version)
+	}
+	newLevel := releaseLines[releaseStart].level
+
+	if strings.TrimSpace(existing) == "" {
+		return strings.TrimRight(release, "\n") + "\n", nil
+	}
+
+	body, links := splitLinkReferences(releaseLines[releaseStart:])
+
+	lines := parseMarkdownLines(existing)
+	existingLevel := releaseLevel(lines)
+	newRelease := renderReleaseLines(body, existingLevel-newLevel)
+
+	updated, replacedUnreleased, err := insertRelease(existing, lines, existingLevel, newRelease, versionPattern, force)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", err, version)
+	}
+	if replacedUnreleased {
+		updated = updateUnreleasedLink(updated, version)
+	}
+
+	return mergeLinkReferences(updated, links), nil
+}
+
+// insertRelease also reports whether the release took the place of an Unreleased section.
+// HasRelease reports whether existing already has a release heading for version, which
+// PrependRelease refuses to replace without force.
+func HasRelease(existing string, version string) bool {
+	if version == "" {
+		return false
+	}
+
+	versionPattern := versionHeadingPattern(version)
+	lines := parseMarkdownLines(existing)
+	level := releaseLevel(lines)
+	for _, line := range lines {
+		if line.level == level && versionPattern.MatchString(line.text) {
+			return true
+		}
+	}
+	return false
+}
+
+func versionHeadingPattern(version string) *regexp.Regexp {
+	return regexp.MustCompile(`(^|[^\w.])v?` + regexp.QuoteMeta(strings.TrimPrefix(version, "v")) + `($|[^\w.-])`)
+}
+
+func insertRelease(existing string, lines []markdownLine, level int, release string, versionPattern *regexp.Regexp, force bool) (string, bool, error) {
+	var headings []int
+	for i, line := range lines {
+		if line.level == level {
+			headings = append(headings, i)
+		}
+	}
+
+	for _, index := range headings {
+		if versionPattern.MatchString(lines[index].text) {
+			if !force {
+				return "", false, ErrVersionExists
+			}
+			return replaceSection(existing, lines, index, level, release), false, nil
+		}
+	}
+
+	for _, index := range headings {
+		if unreleasedPattern.MatchString(lines[index].text) {
+			return replaceSection(existing, lines, index, level, release), true, nil
+		}
+	}
+
+	if len(headings) > 0 {
+		offset := lines[headings[0]].offset
+		return existing[:offset] + release + "\n" + existing[offset:], false, nil
+	}
+
+	return appendBlock(existing, release), false, nil
+}
+
+// updateUnreleasedLink points a "compare/<tag>...HEAD" reference for Unreleased at the new
+// version, and drops any other Unreleased reference since nothing before the release is left.
+func updateUnreleasedLink(document string, version string) string {
+	var builder strings.Builder
+	for _, raw := range strings.SplitAfter(document, "\n") {
+		line := strings.TrimRight(raw, "\r\n")
+		if !linkReferencePattern.MatchString(line) || linkLabel(line) != "unreleased" {
+			builder.WriteString(raw)
+			continue
+		}
+		if match := unreleasedLinkPattern.FindStringSubmatch(line); match != nil {
+			builder.WriteString(match[1] + version + match[2] + raw[len(line):])
+		}
+	}
+	return builder.String()
+}
+
+func appendBlock(existing string, block string) string {
+	switch {
+	case strings.HasSuffix(existing, "\n\n"):
+		return existing + block
+	case strings.HasSuffix(existing, "\n"):
+		return existing + "\n" + block
+	default:
+		return existing + "\n\n" + block
+	}
+}
+
+func splitLinkReferences(lines []markdownLine) ([]markdownLine, []string) {
+	end := len(lines)
+	for end > 0 && (strings.TrimSpace(lines[end-1].text) == "" || linkReferencePattern.MatchString(lines[end-1].text)) {
+		end--
+	}
+
+	var links []string
+	for _, line := range lines[end:] {
+		if linkReferencePattern.MatchString(line.text) {
+			links = append(links, line.text)
+		}
+	}
+
+	return lines[:end], links
+}
+
+func mergeLinkReferences(document string, links []string) string {
+	if len(links) == 0 {
+		return document
+	}
+
+	lines := parseMarkdownLines(document)
+	blockStart := len(lines)
+	for blockStart > 0 && (strings.TrimSpace(lines[blockStart-1].text) == "" || linkReferencePattern.MatchString(lines[blockStart-1].text)) {
+		blockStart--
+	}
+	for blockStart < len(lines) && !linkReferencePattern.MatchString(lines[blockStart].text) {
+		blockStart++
+	}
+
+	if blockStart == len(lines) {
+		return appendBlock(document, strings.Join(links, "\n")+"\n")
+	}
+
+	existing := make(map[string]int)
+	for i := blockStart; i < len(lines); i++ {
+		if linkReferencePattern.MatchString(lines[i].text) {
+			existing[linkLabel(lines[i].text)] = i
+		}
+	}
+
+	replaced := make(map[int]string)
+	var added []string
+	for _, link := range links {
+		if index, ok := existing[linkLabel(link)]; ok {
+			replaced[index] = link
+		} else {
+			added = append(added, link)
+		}
+	}
+
+	var builder strings.Builder
+	builder.WriteString(document[:lines[blockStart].offset])
+	for _, link := range added {
+		builder.WriteString(link + "\n")
+	}
+	for i := blockStart; i < len(lines); i++ {
+		end := len(document)
+		if i+1 < len(lines) {
+			end = lines[i+1].offset
+		}
+		if link, ok := replaced[i]; ok {
+			builder.WriteString(link + document[lines[i].offset+len(lines[i].text):end])
+			continue
+		}
+		builder.WriteString(document[lines[i].offset:end])
+	}
+
+	return builder.String()
+}
+
+func linkLabel(line string) string {
+	return strings.ToLower(line[1:strings.Index(line, "]")])
+}
+
+func parseMarkdownLines(text string) []markdownLine {
+	var lines []markdownLine
+	offset := 0
+	inFence := false
+
+	for _, raw := range strings.SplitAfter(text, "\n") {
+		if raw == "" {
+			continue
+		}
+
+		line := markdownLine{text: strings.TrimRight(raw, "\r\n"), offset: offset}
+		if strings.HasPrefix(strings.TrimSpace(line.text), "```") {
+			inFence = !inFence
+		} else if !inFence {
+			line.level = headingLevel(line.text)
+		}
+
+		lines = append(lines, line)
+		offset += len(raw)
+	}
+
+	return lines
+}
+
+func headingLevel(line string) int {
+	level := 0
+	for level < len(line) && line[level] == '#' {
+		level++
+	}
+	if level == 0 || level > 6 || level == len(line) || line[level] != ' ' {
+		return 0
+	}
+	return level
+}
+
+func releaseLevel(lines []markdownLine) int {
+	level := 0
+	hasTitle := false
+	firstHeading := true
+
+	for _, line := range lines {
+		if line.level == 0 {
+			continue
+		}
+
+		isRelease := releaseHeadingPattern.MatchString(line.text)
+		if firstHeading && line.level == 1 && !isRelease {
+			hasTitle = true
+		}
+		firstHeading = false
+
+		if isRelease && (level == 0 || line.level < level) {
+			level = line.level
+		}
+	}
+
+	if level != 0 {
+		return level
+	}
+	if hasTitle {
+		return 2
+	}
+	return 1
+}
+
+func renderReleaseLines(lines []markdownLine, shift int) string {
+	var builder strings.Builder
+
+	for _, line := range lines {
+		text := line.text
+		if line.level > 0 && shift != 0 {
+			level := line.level + shift
+			if level < 1 {
+				level = 1
+			}
+			if level > 6 {
+				level = 6
+			}
+			text = strings.Repeat("#", level) + text[line.level:]
+		}
+		builder.WriteString(text + "\n")
+	}
+
+	return strings.TrimRight(builder.String(), "\n") + "\n"
+}
+
+func replaceSection(existing string, lines []markdownLine, start int, level int, release string) string {
+	end := len(lines)
+	for i := start + 1; i < len(lines); i++ {
+		if lines[i].level > 0 && lines[i].level <= level {
+			end = i
+			break
+		}
+	}
+
+	// Link reference definitions at the bottom of the file belong to the whole document.
+	if end == len(lines) {
+		for end > start+1 && (strings.TrimSpace(lines[end-1].text) == "" || linkReferencePattern.MatchString(lines[end-1].text)) {
+			end--
+		}
+	}
+
+	startOffset := lines[start].offset
+	endOffset := len(existing)
+	if end < len(lines) {
+		endOffset = lines[end].offset
+	}
+
+	section := existing[startOffset:endOffset]
+	trailing := section[len(strings.TrimRight(section, "\r\n")):]
+	if trailing == "" && endOffset < len(existing) {
+		trailing = "\n"
+	}
+
+	return existing[:startOffset] + strings.TrimRight(release, "\n") + trailing + existing[endOffset:]
+}
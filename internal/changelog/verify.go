@@ -0,0 +1,208 @@
+package changelog
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/brognilucas/ai-changelog/internal/git"
+)
+
+const unverifiedSuffix = " (unverified)"
+
+// Commits the prompt tells the model to leave out; they do not count against coverage.
+var internalCategories = map[string]bool{
+	CategoryRefactor: true,
+	CategoryChore:    true,
+	CategoryTest:     true,
+	CategoryStyle:    true,
+}
+
+// minCitationLength is the length of git's short hashes; shorter hex runs such as "(2024)" or
+// "(cafe)" are ordinary words, not citations.
+const minCitationLength = 7
+
+var citationPattern = regexp.MustCompile(`\s*\(([0-9a-fA-F]{7,40}(?:\s*,\s*[0-9a-fA-F]{7,40})*)\)\s*$`)
+
+// Verification is the result of checking LLM entries against the commits of the release.
+// Cited is false when no entry cites anything, e.g. with a prompt template that does not ask
+// for citations; the other fields are then empty.
+type Verification struct {
+	Cited     bool
+	Uncited   []string
+	Covered   []string
+	Uncovered []git.Commit
+}
+
+// Coverage is the share of user-facing commits cited by at least one entry.
+func (v Verification) Coverage() float64 {
+	total := len(v.Covered) + len(v.Uncovered)
+	if total == 0 {
+		return 1
+	}
+	return float64(len(v.Covered)) / float64(total)
+}
+
+func (v Verification) Total() int {
+	return len(v.Covered) + len(v.Uncovered)
+}
+
+type citationChecker struct {
+	commits []git.Commit
+	cited   map[string]bool
+}
+
+func newCitationChecker(commits []git.Commit) *citationChecker {
+	return &citationChecker{commits: commits, cited: make(map[string]bool)}
+}
+
+// check returns the citations that name a commit of the release and records them as covered.
+func (c *citationChecker) check(citations []string) []string {
+	var valid []string
+	for _, citation := range citations {
+		if commit, ok := c.lookup(citation); ok {
+			valid = append(valid, citation)
+			c.cited[commit.Hash] = true
+		}
+	}
+	return valid
+}
+
+func (c *citationChecker) lookup(citation string) (git.Commit, bool) {
+	citation = strings.ToLower(citation)
+	if len(citation) < minCitationLength {
+		return git.Commit{}, false
+	}
+	for _, commit := range c.commits {
+		if strings.HasPrefix(strings.ToLower(commit.Hash), citation) {
+			return commit, true
+		}
+	}
+	return git.Commit{}, false
+}
+
+func (c *citationChecker) verification(uncited []string) Verification {
+	result := Verification{Cited: true, Uncited: uncited}
+	for _, commit := range c.commits {
+		switch {
+		case c.cited[commit.Hash]:
+			result.Covered = append(result.Covered, commit.Hash)
+		case commit.Breaking || !internalCategories[commit.Prefix]:
+			result.Uncovered = append(result.Uncovered, commit)
+		}
+	}
+	return result
+}
+
+// VerifyEntries keeps the citations of each entry that name a commit in the range. Entries
+// left without one are dropped, or kept and marked as unverified when keepUncited is set.
+func VerifyEntries(entries []Entry, commits []git.Commit, keepUncited bool) ([]Entry, Verification) {
+	checker := newCitationChecker(commits)
+	var verified []Entry
+	var uncited []string
+
+	for _, entry := range entries {
+		entry.Commits = checker.check(entry.Commits)
+		if len(entry.Commits) == 0 {
+			uncited = append(uncited, entry.Text)
+			if !keepUncited {
+				continue
+			}
+			entry.Text += unverifiedSuffix
+		}
+		verified = append(verified, entry)
+	}
+
+	return verified, checker.verification(uncited)
+}
+
+// VerifyMarkdown applies VerifyEntries to the "- " entries of a Markdown changelog, which are
+// expected to end with their citations in parentheses. Headings whose entries were all dropped
+// are removed too.
+func VerifyMarkdown(text string, commits []git.Commit, keepUncited bool) (string, Verification) {
+	if !hasCitation(text) {
+		return text, Verification{}
+	}
+
+	checker := newCitationChecker(commits)
+	var uncited []string
+	var output []string
+	var section []string
+	sectionEntries, sectionDropped := 0, 0
+
+	flush := func() {
+		if sectionEntries > 0 || sectionDropped == 0 {
+			output = append(output, section...)
+		}
+		section, sectionEntries, sectionDropped = nil, 0, 0
+	}
+
+	for _, line := range strings.Split(text, "\n") {
+		if strings.HasPrefix(line, "#") {
+			flush()
+		}
+
+		entry, ok := markdownEntry(line)
+		if !ok {
+			section = append(section, line)
+			continue
+		}
+
+		var citations []string
+		if match := citationPattern.FindStringSubmatch(line); match != nil {
+			citations = splitCitations(match[1])
+		}
+		if len(checker.check(citations)) > 0 {
+			section = append(section, line)
+			sectionEntries++
+			continue
+		}
+
+		uncited = append(uncited, entry)
+		if keepUncited {
+			section = append(section, strings.TrimRight(line, " ")+unverifiedSuffix)
+			sectionEntries++
+		} else {
+			sectionDropped++
+		}
+	}
+	flush()
+
+	return strings.Join(output, "\n"), checker.verification(uncited)
+}
+
+func hasCitation(text string) bool {
+	for _, entry := range markdownEntries(text) {
+		if citationPattern.MatchString(entry) {
+			return true
+		}
+	}
+	return false
+}
+
+func markdownEntries(text string) []string {
+	var entries []string
+	for _, line := range strings.Split(text, "\n") {
+		if _, ok := markdownEntry(line); ok {
+			entries = append(entries, line)
+		}
+	}
+	return entries
+}
+
+func markdownEntry(line string) (string, bool) {
+	trimmed := strings.TrimSpace(line)
+	for _, bullet := range []string{"- ", "* "} {
+		if strings.HasPrefix(trimmed, bullet) {
+			return strings.TrimSpace(citationPattern.ReplaceAllString(trimmed[len(bullet):], "")), true
+		}
+	}
+	return "", false
+}
+
+func splitCitations(list string) []string {
+	var citations []string
+	for _, citation := range strings.Split(list, ",") {
+		citations = append(citations, strings.TrimSpace(citation))
+	}
+	return citations
+}
@@ -0,0 +1,124 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+const DefaultFile = ".ai-changelog.json"
+
+// RepositorySettings are the keys a discovered DefaultFile may set. A file checked into a
+// repository is not trusted to decide where commits are sent, whether they are redacted, which
+// revisions are read or which files are written; those settings need a file passed with --config.
+var RepositorySettings = map[string]bool{
+	"model":           true,
+	"temperature":     true,
+	"top-p":           true,
+	"seed":            true,
+	"num-ctx":         true,
+	"num-predict":     true,
+	"context-length":  true,
+	"keep-alive":      true,
+	"warm-up":         true,
+	"timeout":         true,
+	"llm-timeout":     true,
+	"retries":         true,
+	"retry-delay":     true,
+	"prompt-template": true,
+	"sections":        true,
+	"redact":          true,
+	"format":          true,
+	"group-by":        true,
+	"llm-output":      true,
+	"uncited":         true,
+	"strict":          true,
+	"min-coverage":    true,
+	"examples":        true,
+	"ollama-api":      true,
+	"tag-pattern":     true,
+	"skip-prerelease": true,
+	"repo-url":        true,
+}
+
+// Config holds repository defaults keyed by flag name, e.g. {"model": "mistral", "seed": 7}.
+type Config map[string]any
+
+func Load(path string) (Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+
+	var config Config
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse config %s: %w", path, err)
+	}
+
+	return config, nil
+}
+
+// CheckRepositorySettings rejects keys outside RepositorySettings, for a file that was found in
+// the working directory rather than given with --config.
+func (c Config) CheckRepositorySettings(path string) error {
+	var rejected []string
+	for key := range c {
+		if !RepositorySettings[key] {
+			rejected = append(rejected, key)
+		}
+	}
+	if len(rejected) == 0 {
+		return nil
+	}
+
+	sort.Strings(rejected)
+	return fmt.Errorf("%s cannot set %s; pass it with --config to allow these settings", path, strings.Join(rejected, ", "))
+}
+
+// Apply sets every flag that was not given on the command line, so flags always win.
+func (c Config) Apply(flags *pflag.FlagSet) error {
+	keys := make([]string, 0, len(c))
+	for key := range c {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		flag := flags.Lookup(key)
+		if flag == nil {
+			return fmt.Errorf("unknown setting %q in config", key)
+		}
+		if flag.Changed {
+			continue
+		}
+
+		values, ok := c[key].([]any)
+		if !ok {
+			values = []any{c[key]}
+		}
+
+		for _, value := range values {
+			if err := flags.Set(key, formatValue(value)); err != nil {
+				return fmt.Errorf("invalid value for %q in config: %w", key, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func formatValue(value any) string {
+	switch v := value.(type) {
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case string:
+		return v
+	default:
+		return fmt.Sprint(v)
+	}
+}
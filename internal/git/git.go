@@ -1,7 +1,13 @@
 package git
 
 import (
+	"bufio"
+	"context"
 	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
 	"os/exec"
 	"strconv"
 	"strings"
@@ -9,15 +15,18 @@ import (
 )
 
 type Runner interface {
-	Run(args ...string) (string, error)
+	Run(ctx context.Context, args ...string) (string, error)
 }
 
 type DefaultRunner struct{}
 
-func (r *DefaultRunner) Run(args ...string) (string, error) {
-	cmd := exec.Command("git", args...)
+func (r *DefaultRunner) Run(ctx context.Context, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
 	output, err := cmd.Output()
 	if err != nil {
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
 		return "", err
 	}
 	return string(output), nil
@@ -26,9 +35,15 @@ func (r *DefaultRunner) Run(args ...string) (string, error) {
 type Commit struct {
 	Hash      string
 	Subject   string
+	Scope     string
+	Body      string
+	Trailers  map[string][]string
 	Author    string
 	Timestamp time.Time
 	Prefix    string
+
+	Breaking            bool
+	BreakingDescription string
 }
 
 var validPrefixes = map[string]bool{
@@ -42,32 +57,148 @@ var validPrefixes = map[string]bool{
 	"perf":     true,
 }
 
-func ParseCommitLine(line string) (Commit, error) {
-	parts := strings.Split(line, "|")
-	if len(parts) != 4 {
-		return Commit{}, errors.New("invalid commit line format: expected 4 pipe-separated fields")
+const (
+	fieldSeparator = "\x1f"
+	logFormat      = "%H%x1f%ct%x1f%an%x1f%s%x1f%b"
+	recordFields   = 5
+)
+
+func ParseCommitRecord(record string) (Commit, error) {
+	fields := strings.SplitN(record, fieldSeparator, recordFields)
+	if len(fields) != recordFields {
+		return Commit{}, errors.New("invalid commit record format: expected 5 separated fields")
 	}
 
-	timestamp, err := strconv.ParseInt(parts[3], 10, 64)
+	hash := strings.TrimSpace(fields[0])
+	if hash == "" {
+		return Commit{}, errors.New("invalid commit record: missing hash")
+	}
+
+	timestamp, err := strconv.ParseInt(fields[1], 10, 64)
 	if err != nil {
 		return Commit{}, errors.New("invalid timestamp: must be a unix timestamp")
 	}
 
+	body, trailers := ParseBody(fields[4])
+
 	return Commit{
-		Hash:      parts[0],
-		Subject:   parts[1],
-		Author:    parts[2],
+		Hash:      hash,
+		Subject:   fields[3],
+		Body:      body,
+		Trailers:  trailers,
+		Author:    fields[2],
 		Timestamp: time.Unix(timestamp, 0),
 	}, nil
 }
 
+func ParseLog(r io.Reader, warnings io.Writer) ([]Commit, error) {
+	if warnings == nil {
+		warnings = io.Discard
+	}
+
+	reader := bufio.NewReader(r)
+	commits := []Commit{}
+	index := 0
+
+	for {
+		record, readErr := reader.ReadString(0)
+		if readErr != nil && readErr != io.EOF {
+			return nil, fmt.Errorf("failed to read git log output: %w", readErr)
+		}
+
+		record = strings.TrimSuffix(record, "\x00")
+		if strings.TrimSpace(record) != "" {
+			index++
+			commit, err := ParseCommitRecord(record)
+			if err != nil {
+				fmt.Fprintf(warnings, "Warning: skipping malformed commit record #%d (%q): %v\n", index, previewRecord(record), err)
+			} else {
+				commit.Prefix = ExtractPrefix(commit.Subject)
+				commit.Scope = ExtractScope(commit.Subject)
+				commit.Breaking, commit.BreakingDescription = DetectBreaking(commit)
+				commits = append(commits, commit)
+			}
+		}
+
+		if readErr == io.EOF {
+			return commits, nil
+		}
+	}
+}
+
+const recordPreviewLength = 60
+
+func previewRecord(record string) string {
+	preview := strings.ReplaceAll(strings.TrimSpace(record), fieldSeparator, " ")
+	if len(preview) > recordPreviewLength {
+		return preview[:recordPreviewLength] + "..."
+	}
+	return preview
+}
+
+func ParseBody(raw string) (string, map[string][]string) {
+	body := strings.TrimSpace(strings.ReplaceAll(raw, "\r\n", "\n"))
+	if body == "" {
+		return "", nil
+	}
+
+	paragraphStart := strings.LastIndex(body, "\n\n")
+	trailers, ok := parseTrailerBlock(strings.TrimSpace(body[paragraphStart+1:]))
+	if !ok {
+		return body, nil
+	}
+
+	if paragraphStart == -1 {
+		return "", trailers
+	}
+	return strings.TrimSpace(body[:paragraphStart]), trailers
+}
+
+func parseTrailerBlock(block string) (map[string][]string, bool) {
+	trailers := make(map[string][]string)
+	lastKey := ""
+
+	for _, line := range strings.Split(block, "\n") {
+		if lastKey != "" && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			values := trailers[lastKey]
+			values[len(values)-1] += " " + strings.TrimSpace(line)
+			continue
+		}
+
+		key, value, found := strings.Cut(line, ": ")
+		if !found || !isTrailerKey(key) {
+			return nil, false
+		}
+
+		trailers[key] = append(trailers[key], strings.TrimSpace(value))
+		lastKey = key
+	}
+
+	return trailers, len(trailers) > 0
+}
+
+func isTrailerKey(key string) bool {
+	if key == "BREAKING CHANGE" {
+		return true
+	}
+	if key == "" {
+		return false
+	}
+	for _, r := range key {
+		if !(r == '-' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9')) {
+			return false
+		}
+	}
+	return true
+}
+
 func ExtractPrefix(subject string) string {
 	colonIndex := strings.Index(subject, ":")
 	if colonIndex == -1 {
 		return "other"
 	}
 
-	prefix := subject[:colonIndex]
+	prefix := strings.TrimSuffix(subject[:colonIndex], "!")
 
 	parenIndex := strings.Index(prefix, "(")
 	if parenIndex != -1 {
@@ -81,46 +212,185 @@ func ExtractPrefix(subject string) string {
 	return "other"
 }
 
+func ExtractScope(subject string) string {
+	colonIndex := strings.Index(subject, ":")
+	if colonIndex == -1 {
+		return ""
+	}
+
+	header := strings.TrimSuffix(subject[:colonIndex], "!")
+
+	parenIndex := strings.Index(header, "(")
+	if parenIndex <= 0 || !strings.HasSuffix(header, ")") {
+		return ""
+	}
+
+	return strings.TrimSpace(header[parenIndex+1 : len(header)-1])
+}
+
 type CommitReader struct {
-	runner Runner
+	runner   Runner
+	warnings io.Writer
 }
 
 func NewCommitReader(runner Runner) *CommitReader {
-	return &CommitReader{runner: runner}
+	return &CommitReader{runner: runner, warnings: os.Stderr}
 }
 
-func (r *CommitReader) GetCommits(since string) ([]Commit, error) {
-	args := []string{"log", "--format=%H|%s|%an|%ct"}
+func NewCommitReaderWithWarnings(runner Runner, warnings io.Writer) *CommitReader {
+	return &CommitReader{runner: runner, warnings: warnings}
+}
 
-	if since != "" {
-		args = append(args, since+"..HEAD")
+func (r *CommitReader) GetCommits(ctx context.Context, commitRange CommitRange) ([]Commit, error) {
+	rangeArgs, err := commitRange.LogArgs()
+	if err != nil {
+		return nil, err
 	}
 
-	output, err := r.runner.Run(args...)
+	args := append([]string{"log", "-z", "--format=" + logFormat}, rangeArgs...)
+
+	output, err := r.runner.Run(ctx, args...)
 	if err != nil {
 		return nil, err
 	}
 
-	if strings.TrimSpace(output) == "" {
-		return []Commit{}, nil
+	return ParseLog(strings.NewReader(output), r.warnings)
+}
+
+func RepositoryURL(ctx context.Context, runner Runner, remote string) (string, error) {
+	output, err := runner.Run(ctx, "remote", "get-url", remote)
+	if err != nil {
+		return "", err
 	}
+	return NormalizeRemoteURL(strings.TrimSpace(output)), nil
+}
 
-	lines := strings.Split(strings.TrimSpace(output), "\n")
-	commits := make([]Commit, 0, len(lines))
+func NormalizeRemoteURL(remote string) string {
+	remote = strings.TrimSuffix(strings.TrimSuffix(remote, "/"), ".git")
 
-	for _, line := range lines {
-		if line == "" {
-			continue
+	if rest, found := strings.CutPrefix(remote, "git@"); found {
+		if host, path, ok := strings.Cut(rest, ":"); ok {
+			return "https://" + host + "/" + path
 		}
+	}
 
-		commit, err := ParseCommitLine(line)
-		if err != nil {
-			continue
+	parsed, err := url.Parse(remote)
+	if err != nil || parsed.Host == "" {
+		return remote
+	}
+
+	return "https://" + parsed.Hostname() + parsed.Path
+}
+
+type CommitRange struct {
+	Since string
+	Until string
+}
+
+func (r CommitRange) LogArgs() ([]string, error) {
+	for _, value := range []string{r.Since, r.Until} {
+		if err := checkRevision(value); err != nil {
+			return nil, err
+		}
+	}
+
+	var args []string
+	target := ""
+
+	if r.Until != "" {
+		if IsDate(r.Until) {
+			args = append(args, "--until="+r.Until)
+		} else {
+			target = r.Until
+		}
+	}
+
+	switch {
+	case r.Since == "":
+		if target != "" {
+			args = append(args, target)
+		}
+	case strings.Contains(r.Since, ".."):
+		if r.Until != "" {
+			return nil, fmt.Errorf("cannot combine revision range %q with an until value", r.Since)
+		}
+		args = append(args, r.Since)
+	case IsDate(r.Since):
+		args = append(args, "--since="+r.Since)
+		if target != "" {
+			args = append(args, target)
 		}
+	default:
+		if target == "" {
+			target = "HEAD"
+		}
+		args = append(args, r.Since+".."+target)
+	}
+
+	return args, nil
+}
+
+// checkRevision rejects values git would read as an option rather than a revision, such as
+// "--output=/path".
+func checkRevision(value string) error {
+	if strings.HasPrefix(value, "-") {
+		return fmt.Errorf("invalid revision %q: must not start with \"-\"", value)
+	}
+	return nil
+}
+
+func (r CommitRange) Target() string {
+	if r.Until == "" || IsDate(r.Until) {
+		return "HEAD"
+	}
+	return r.Until
+}
+
+var dateLayouts = []string{
+	"2006-01-02",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	time.RFC3339,
+}
+
+// ShortHash abbreviates a commit hash to the seven characters git shows by default.
+func ShortHash(hash string) string {
+	if len(hash) > 7 {
+		return hash[:7]
+	}
+	return hash
+}
+
+func IsDate(value string) bool {
+	for _, layout := range dateLayouts {
+		if _, err := time.Parse(layout, value); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+var breakingTrailerKeys = []string{"BREAKING CHANGE", "BREAKING-CHANGE"}
+
+func DetectBreaking(commit Commit) (bool, string) {
+	for _, key := range breakingTrailerKeys {
+		if values := commit.Trailers[key]; len(values) > 0 {
+			return true, strings.Join(values, "; ")
+		}
+	}
+
+	for _, line := range strings.Split(commit.Body, "\n") {
+		for _, key := range breakingTrailerKeys {
+			if description, found := strings.CutPrefix(line, key+": "); found {
+				return true, strings.TrimSpace(description)
+			}
+		}
+	}
 
-		commit.Prefix = ExtractPrefix(commit.Subject)
-		commits = append(commits, commit)
+	colonIndex := strings.Index(commit.Subject, ":")
+	if colonIndex > 0 && strings.HasSuffix(commit.Subject[:colonIndex], "!") && !strings.Contains(commit.Subject[:colonIndex], " ") {
+		return true, strings.TrimSpace(commit.Subject[colonIndex+1:])
 	}
 
-	return commits, nil
+	return false, ""
 }
@@ -0,0 +1,127 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Tag struct {
+	Name string
+	Date time.Time
+}
+
+type TagReader struct {
+	runner Runner
+}
+
+func NewTagReader(runner Runner) *TagReader {
+	return &TagReader{runner: runner}
+}
+
+func (r *TagReader) LatestTag(ctx context.Context, target string, pattern string, skipPrerelease bool) (string, error) {
+	if target == "" {
+		target = "HEAD"
+	}
+	if err := checkRevision(target); err != nil {
+		return "", err
+	}
+
+	// Tags on the target commit itself are excluded so that a release job running on a
+	// freshly tagged commit still gets the previous release as its starting point.
+	args := []string{"tag", "--merged", target, "--no-contains", target, "--sort=-v:refname", "--sort=-creatordate"}
+	if pattern != "" {
+		args = append(args, "--list", pattern)
+	}
+
+	output, err := r.runner.Run(ctx, args...)
+	if err != nil {
+		return "", err
+	}
+
+	for _, tag := range strings.Split(output, "\n") {
+		tag = strings.TrimSpace(tag)
+		if tag == "" {
+			continue
+		}
+		if skipPrerelease && IsPrerelease(tag) {
+			continue
+		}
+		return tag, nil
+	}
+
+	return "", nil
+}
+
+// semverPattern matches a version at the end of a tag, after any prefix such as "v" or
+// "release-": MAJOR.MINOR[.PATCH], an optional pre-release and optional build metadata.
+var semverPattern = regexp.MustCompile(`(?:^|[^\d.])v?\d+\.\d+(?:\.\d+)?(?:-([0-9A-Za-z.-]+))?(?:\+[0-9A-Za-z.-]+)?$`)
+
+// IsPrerelease reports whether the tag's version has a semver pre-release component, as in
+// v1.2.0-rc.1. Tags without a dotted version, such as release-2024-01-01 or v2-final, are not
+// pre-releases.
+func IsPrerelease(tag string) bool {
+	match := semverPattern.FindStringSubmatch(tag)
+	if match == nil || match[1] == "" {
+		return false
+	}
+
+	for _, identifier := range strings.Split(match[1], ".") {
+		if identifier == "" {
+			return false
+		}
+		// Numeric identifiers must not have leading zeros, so "-01-15" is a date, not a pre-release.
+		if _, err := strconv.Atoi(identifier); err == nil && len(identifier) > 1 && identifier[0] == '0' {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *TagReader) ListTags(ctx context.Context, pattern string, skipPrerelease bool) ([]Tag, error) {
+	args := []string{"tag", "--sort=v:refname", "--sort=creatordate", "--format=%(refname:short)%1f%(creatordate:unix)"}
+	if pattern != "" {
+		args = append(args, "--list", pattern)
+	}
+
+	output, err := r.runner.Run(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	tags := []Tag{}
+	for _, line := range strings.Split(output, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		tag, err := ParseTagLine(line)
+		if err != nil {
+			return nil, err
+		}
+
+		if skipPrerelease && IsPrerelease(tag.Name) {
+			continue
+		}
+		tags = append(tags, tag)
+	}
+
+	return tags, nil
+}
+
+func ParseTagLine(line string) (Tag, error) {
+	name, rawDate, found := strings.Cut(line, fieldSeparator)
+	if !found || name == "" {
+		return Tag{}, errors.New("invalid tag line format: expected name and date")
+	}
+
+	timestamp, err := strconv.ParseInt(strings.TrimSpace(rawDate), 10, 64)
+	if err != nil {
+		return Tag{}, errors.New("invalid tag date: must be a unix timestamp")
+	}
+
+	return Tag{Name: name, Date: time.Unix(timestamp, 0)}, nil
+}
@@ -0,0 +1,52 @@
+package llm
+
+import (
+	"context"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/brognilucas/ai-changelog/internal/git"
+)
+
+// Client is what every provider implements; the optional capabilities below are checked
+// with type assertions.
+type Client interface {
+	HealthCheck(ctx context.Context) error
+	SummarizeCommits(ctx context.Context, commits []git.Commit, model string) ([]string, error)
+	GenerateChangelog(ctx context.Context, request ChangelogRequest) (string, error)
+}
+
+type StreamingClient interface {
+	GenerateChangelogStream(ctx context.Context, request ChangelogRequest, onToken func(string)) (string, error)
+}
+
+type WarmUpper interface {
+	WarmUp(ctx context.Context, model string) error
+}
+
+type ModelManager interface {
+	CheckModel(ctx context.Context, model string) error
+	PullModel(ctx context.Context, model string, progress io.Writer) error
+}
+
+const DefaultTimeout = 120 * time.Second
+
+// Message has the same shape in Ollama's chat API as in the chat completions API.
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// ChatMessages sends the prompt rules as a system message, apart from the commit data, with
+// any example or refinement turns in between.
+func ChatMessages(messages Messages) []Message {
+	var chat []Message
+	if system := strings.TrimSpace(messages.System); system != "" {
+		chat = append(chat, Message{Role: "system", Content: system})
+	}
+	for _, turn := range messages.Turns {
+		chat = append(chat, Message{Role: turn.Role, Content: turn.Content})
+	}
+	return append(chat, Message{Role: RoleUser, Content: strings.TrimSpace(messages.User)})
+}
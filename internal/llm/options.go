@@ -0,0 +1,25 @@
+package llm
+
+// ModelOptions are the sampling options sent with every request. Low temperature and a fixed
+// seed keep the release notes stable between runs over the same commits.
+type ModelOptions struct {
+	Temperature float64 `json:"temperature"`
+	Seed        int     `json:"seed"`
+	TopP        float64 `json:"top_p"`
+	NumCtx      int     `json:"num_ctx,omitempty"`
+	NumPredict  int     `json:"num_predict,omitempty"`
+}
+
+const (
+	DefaultTemperature = 0.2
+	DefaultSeed        = 42
+	DefaultTopP        = 0.9
+)
+
+func DefaultModelOptions() ModelOptions {
+	return ModelOptions{
+		Temperature: DefaultTemperature,
+		Seed:        DefaultSeed,
+		TopP:        DefaultTopP,
+	}
+}
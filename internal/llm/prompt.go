@@ -0,0 +1,391 @@
+package llm
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/brognilucas/ai-changelog/internal/git"
+)
+
+//go:embed templates/changelog.tmpl
+var defaultTemplateSource string
+
+var defaultTemplate = mustParsePromptTemplate(defaultTemplateSource)
+
+// PromptTemplate is a text/template that renders the changelog prompt from PromptData. A
+// template may define a "format" block describing the expected output; merge prompts for
+// large ranges reuse it so the merged changelog keeps the template's sections.
+type PromptTemplate struct {
+	Source   string
+	template *template.Template
+}
+
+// PromptData is what a prompt template receives.
+type PromptData struct {
+	Commits         []git.Commit
+	Version         string
+	Since           string
+	Until           string
+	Scopes          []string
+	BreakingChanges []git.Commit
+}
+
+// ChangelogRequest describes one changelog to write. A nil Template uses the default prompt.
+// Non-empty Sections ask for a StructuredChangelog limited to those sections instead of Markdown.
+// Examples and Refinements are only used by chat APIs: examples are earlier releases shown as
+// previous turns, refinements are follow-up instructions applied to the finished changelog.
+// Warnings receives notices such as commits truncated to fit the context window; nil discards them.
+type ChangelogRequest struct {
+	Commits     []git.Commit
+	Model       string
+	Version     string
+	Since       string
+	Until       string
+	Template    *PromptTemplate
+	Sections    []string
+	Examples    []Example
+	Refinements []string
+	Warnings    io.Writer
+}
+
+// Example is an earlier release: the commits it was written from and its changelog.
+type Example struct {
+	Version   string
+	Commits   []git.Commit
+	Changelog string
+}
+
+var templateFuncs = template.FuncMap{
+	"shortHash": git.ShortHash,
+	"details":   formatCommitDetails,
+	"join":      strings.Join,
+	"trim":      strings.TrimSpace,
+}
+
+func DefaultPromptTemplate() *PromptTemplate {
+	return defaultTemplate
+}
+
+func ParsePromptTemplate(source string) (*PromptTemplate, error) {
+	parsed, err := template.New("prompt").Funcs(templateFuncs).Parse(source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse prompt template: %w", err)
+	}
+	return &PromptTemplate{Source: source, template: parsed}, nil
+}
+
+func LoadPromptTemplate(path string) (*PromptTemplate, error) {
+	source, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read prompt template: %w", err)
+	}
+	return ParsePromptTemplate(string(source))
+}
+
+func mustParsePromptTemplate(source string) *PromptTemplate {
+	parsed, err := ParsePromptTemplate(source)
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}
+
+func (t *PromptTemplate) Execute(data PromptData) (string, error) {
+	var builder strings.Builder
+	if err := t.template.Execute(&builder, data); err != nil {
+		return "", fmt.Errorf("failed to render prompt template: %w", err)
+	}
+	return builder.String(), nil
+}
+
+func (t *PromptTemplate) executeBlock(name string, data PromptData) (string, error) {
+	var builder strings.Builder
+	if err := t.template.ExecuteTemplate(&builder, name, data); err != nil {
+		return "", fmt.Errorf("failed to render prompt template: %w", err)
+	}
+	return builder.String(), nil
+}
+
+// outputFormat renders the template's "format" block, falling back to the default one.
+func (t *PromptTemplate) outputFormat(data PromptData) (string, error) {
+	source := t
+	if t.template.Lookup("format") == nil {
+		source = defaultTemplate
+	}
+	return source.executeBlock("format", data)
+}
+
+// Sections are the "## " headings of the template's "format" block, in order. A template
+// without one does not declare its sections and gets none.
+func (t *PromptTemplate) Sections() []string {
+	if t.template.Lookup("format") == nil {
+		return nil
+	}
+	format, err := t.executeBlock("format", PromptData{})
+	if err != nil {
+		return nil
+	}
+
+	var sections []string
+	for _, line := range strings.Split(format, "\n") {
+		if heading, ok := strings.CutPrefix(strings.TrimSpace(line), "## "); ok {
+			sections = append(sections, strings.TrimSpace(heading))
+		}
+	}
+	return sections
+}
+
+func (r ChangelogRequest) template() *PromptTemplate {
+	if r.Template == nil {
+		return defaultTemplate
+	}
+	return r.Template
+}
+
+func (r ChangelogRequest) data(commits []git.Commit) PromptData {
+	commits = SanitizeCommits(commits)
+	data := PromptData{
+		Commits: commits,
+		Version: r.Version,
+		Since:   r.Since,
+		Until:   r.Until,
+	}
+
+	seen := map[string]bool{}
+	for _, commit := range commits {
+		if commit.Scope != "" && !seen[commit.Scope] {
+			seen[commit.Scope] = true
+			data.Scopes = append(data.Scopes, commit.Scope)
+		}
+		if commit.Breaking {
+			data.BreakingChanges = append(data.BreakingChanges, commit)
+		}
+	}
+	sort.Strings(data.Scopes)
+
+	return data
+}
+
+const (
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+)
+
+type Turn struct {
+	Role    string
+	Content string
+}
+
+// Messages is a prompt split into the rules (System) and the commit data they apply to (User),
+// for chat APIs that take them as separate messages. Turns come between the two: few-shot
+// examples, or the conversation being refined.
+type Messages struct {
+	System string
+	Turns  []Turn
+	User   string
+}
+
+// Prompt joins the messages for APIs that take a single prompt. Turns are left out.
+func (m Messages) Prompt() string {
+	return m.System + m.User
+}
+
+func (m Messages) estimate() int {
+	tokens := EstimateTokens(m.System) + EstimateTokens(m.User)
+	for _, turn := range m.Turns {
+		tokens += EstimateTokens(turn.Content)
+	}
+	return tokens
+}
+
+// Prompt renders the changelog prompt for a subset of the request's commits.
+func (r ChangelogRequest) Prompt(commits []git.Commit) (string, error) {
+	messages, err := r.Messages(commits)
+	if err != nil {
+		return "", err
+	}
+	return messages.Prompt(), nil
+}
+
+// Messages renders the changelog prompt for a subset of the request's commits. Templates
+// that define both a "system" and a "user" block are split along them; others are sent
+// entirely as the user message.
+func (r ChangelogRequest) Messages(commits []git.Commit) (Messages, error) {
+	if len(commits) == 0 {
+		return Messages{}, nil
+	}
+
+	t := r.template()
+	data := r.data(commits)
+	var messages Messages
+	var err error
+
+	if t.template.Lookup("system") != nil && t.template.Lookup("user") != nil {
+		if messages.System, err = t.executeBlock("system", data); err != nil {
+			return Messages{}, err
+		}
+		messages.User, err = t.executeBlock("user", data)
+	} else {
+		messages.User, err = t.Execute(data)
+	}
+	if err != nil {
+		return Messages{}, err
+	}
+
+	messages.User += r.structuredSuffix()
+	if messages.Turns, err = r.exampleTurns(); err != nil {
+		return Messages{}, err
+	}
+	return messages, nil
+}
+
+// exampleTurns renders each example as the user message for its commits followed by its
+// changelog as the answer. Examples are Markdown, so structured requests do not use them.
+func (r ChangelogRequest) exampleTurns() ([]Turn, error) {
+	if r.Structured() {
+		return nil, nil
+	}
+
+	var turns []Turn
+	for _, example := range r.Examples {
+		messages, err := ChangelogRequest{Version: example.Version, Template: r.Template}.Messages(example.Commits)
+		if err != nil {
+			return nil, err
+		}
+		if messages.User == "" || strings.TrimSpace(example.Changelog) == "" {
+			continue
+		}
+		turns = append(turns,
+			Turn{Role: RoleUser, Content: strings.TrimSpace(messages.User)},
+			Turn{Role: RoleAssistant, Content: strings.TrimSpace(example.Changelog)},
+		)
+	}
+	return turns, nil
+}
+
+// fitExamples drops the oldest examples until the rest take at most budget tokens, so they
+// never crowd out the commits.
+func (r ChangelogRequest) fitExamples(budget int) ChangelogRequest {
+	for len(r.Examples) > 0 {
+		turns, err := r.exampleTurns()
+		if err != nil || (Messages{Turns: turns}).estimate() <= budget {
+			return r
+		}
+		r.Examples = r.Examples[:len(r.Examples)-1]
+	}
+	return r
+}
+
+func (r ChangelogRequest) mergeMessages(drafts []string) (Messages, error) {
+	format, err := r.template().outputFormat(r.data(r.Commits))
+	if err != nil {
+		return Messages{}, err
+	}
+	return buildMergeMessages(format, r.Sections, drafts, SanitizeCommits(r.Commits)), nil
+}
+
+func (r ChangelogRequest) mergePrompt(drafts []string) (string, error) {
+	messages, err := r.mergeMessages(drafts)
+	if err != nil {
+		return "", err
+	}
+	return messages.Prompt(), nil
+}
+
+func (r ChangelogRequest) Structured() bool {
+	return len(r.Sections) > 0
+}
+
+// Format is the JSON schema the response must follow, or nil for a Markdown changelog.
+func (r ChangelogRequest) Format() json.RawMessage {
+	if !r.Structured() {
+		return nil
+	}
+	return ChangelogSchema(r.Sections)
+}
+
+func (r ChangelogRequest) structuredSuffix() string {
+	if !r.Structured() {
+		return ""
+	}
+	return structuredInstructions(r.Sections)
+}
+
+func BuildChangelogPrompt(commits []git.Commit) string {
+	// The embedded default template cannot fail to render.
+	prompt, _ := ChangelogRequest{Commits: commits}.Prompt(commits)
+	return prompt
+}
+
+func formatBreakingChanges(commits []git.Commit) string {
+	var builder strings.Builder
+
+	for _, commit := range commits {
+		if !commit.Breaking {
+			continue
+		}
+		if builder.Len() == 0 {
+			builder.WriteString("\n<breaking-changes>\nBreaking changes (list each one explicitly under Breaking Changes):\n")
+		}
+		builder.WriteString(fmt.Sprintf("- %s (%s)\n", commit.BreakingDescription, git.ShortHash(commit.Hash)))
+	}
+
+	if builder.Len() > 0 {
+		builder.WriteString("</breaking-changes>\n")
+	}
+	return builder.String()
+}
+
+func formatCommitLine(commit git.Commit) string {
+	return fmt.Sprintf("- %s (%s)\n", commit.Subject, git.ShortHash(commit.Hash)) + formatCommitDetails(commit)
+}
+
+func formatCommitDetails(commit git.Commit) string {
+	var builder strings.Builder
+
+	for _, line := range strings.Split(commit.Body, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		builder.WriteString("  " + strings.TrimSpace(line) + "\n")
+	}
+
+	keys := make([]string, 0, len(commit.Trailers))
+	for key := range commit.Trailers {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		for _, value := range commit.Trailers[key] {
+			builder.WriteString(fmt.Sprintf("  %s: %s\n", key, value))
+		}
+	}
+
+	return builder.String()
+}
+
+func BuildPrompt(commits []git.Commit) string {
+	if len(commits) == 0 {
+		return ""
+	}
+
+	var builder strings.Builder
+	builder.WriteString("You are a changelog generator. Summarize the following git commits into clear, user-friendly changelog entries.\n\n")
+	builder.WriteString("Commits:\n")
+
+	for _, commit := range commits {
+		builder.WriteString(fmt.Sprintf("- %s\n", commit.Subject))
+		builder.WriteString(formatCommitDetails(commit))
+	}
+
+	builder.WriteString("\nGenerate a concise changelog summary grouped by type (features, fixes, etc.).")
+
+	return builder.String()
+}
@@ -0,0 +1,53 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// ChatChangelog writes the changelog with MapReduceChat, then applies the request's
+// refinements: each is sent as a new user turn after the previous answer, so the model revises
+// its own changelog. final is used for the last request, so only the finished text is streamed.
+func ChatChangelog(ctx context.Context, request ChangelogRequest, contextLength int, chat ChatFunc, final ChatFunc) (string, error) {
+	if len(request.Refinements) == 0 {
+		return MapReduceChatWithFinal(ctx, request, contextLength, chat, final)
+	}
+	if contextLength <= 0 {
+		contextLength = DefaultContextLength
+	}
+
+	response, conversation, err := mapReduce(ctx, request, contextLength, chat, chat)
+	if err != nil {
+		return "", err
+	}
+
+	available := contextLength - ResponseReserve(contextLength)
+	for i, refinement := range request.Refinements {
+		conversation.Turns = append(conversation.Turns,
+			Turn{Role: RoleUser, Content: strings.TrimSpace(conversation.User)},
+			Turn{Role: RoleAssistant, Content: strings.TrimSpace(response)},
+		)
+		conversation.User = refinementPrompt(refinement) + request.structuredSuffix()
+		if conversation.estimate() > available {
+			return "", fmt.Errorf("refinement %d does not fit in a context length of %d", i+1, contextLength)
+		}
+
+		send := chat
+		if i == len(request.Refinements)-1 {
+			send = final
+		}
+		if response, err = send(ctx, conversation); err != nil {
+			return response, fmt.Errorf("refinement %d failed: %w", i+1, err)
+		}
+	}
+
+	return response, nil
+}
+
+func refinementPrompt(instruction string) string {
+	return fmt.Sprintf(`Revise the changelog you wrote: %s
+
+Reply with the complete revised changelog in the same format and sections, keeping the commit hashes of every entry. Do NOT add any explanation or commentary.
+`, strings.TrimSpace(instruction))
+}
@@ -0,0 +1,165 @@
+package llm
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+type RetryPolicy struct {
+	MaxRetries int
+	BaseDelay  time.Duration
+	MaxDelay   time.Duration
+}
+
+const (
+	defaultRetryBaseDelay = time.Second
+	defaultRetryMaxDelay  = 30 * time.Second
+	maxErrorBodySize      = 4096
+)
+
+// StatusError is a non-200 response. Server names who answered, for the error message.
+type StatusError struct {
+	Server     string
+	StatusCode int
+	Message    string
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("%s returned status %d", e.Server, e.StatusCode)
+	}
+	return fmt.Sprintf("%s returned status %d: %s", e.Server, e.StatusCode, e.Message)
+}
+
+// NewStatusError reads the error message from the body: Ollama sends {"error": "..."}, OpenAI
+// compatible servers {"error": {"message": "..."}}.
+func NewStatusError(server string, resp *http.Response) *StatusError {
+	statusErr := &StatusError{Server: server, StatusCode: resp.StatusCode}
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+	var payload struct {
+		Error json.RawMessage `json:"error"`
+	}
+	var detail struct {
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(body, &payload) == nil && len(payload.Error) > 0 {
+		if json.Unmarshal(payload.Error, &statusErr.Message) != nil && json.Unmarshal(payload.Error, &detail) == nil {
+			statusErr.Message = detail.Message
+		}
+	}
+	if statusErr.Message == "" {
+		statusErr.Message = strings.TrimSpace(string(body))
+	}
+
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+		statusErr.RetryAfter = time.Duration(seconds) * time.Second
+	}
+
+	return statusErr
+}
+
+// IsRetryable reports whether a failed request may succeed later: refused or reset connections,
+// 429 and 5xx responses (Ollama answers 503 while busy or loading a model). Timeouts are not
+// retried, since --llm-timeout is a limit per request, and neither are unknown hosts, unreachable
+// networks or TLS errors, which point at a misconfiguration that does not go away.
+func IsRetryable(err error) bool {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode == http.StatusTooManyRequests || statusErr.StatusCode >= 500
+	}
+
+	var netErr net.Error
+	var dnsErr *net.DNSError
+	if (errors.As(err, &netErr) && netErr.Timeout()) || errors.As(err, &dnsErr) || isTLSError(err) {
+		return false
+	}
+
+	return errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, io.EOF)
+}
+
+func isTLSError(err error) bool {
+	var verificationErr *tls.CertificateVerificationError
+	var recordErr tls.RecordHeaderError
+	var authorityErr x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var invalidErr x509.CertificateInvalidError
+	if errors.As(err, &verificationErr) || errors.As(err, &recordErr) || errors.As(err, &authorityErr) ||
+		errors.As(err, &hostnameErr) || errors.As(err, &invalidErr) {
+		return true
+	}
+
+	// TLS alerts sent by the server, e.g. "remote error: tls: bad certificate".
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "remote error"
+}
+
+func (p RetryPolicy) delay(attempt int, err error) time.Duration {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) && statusErr.RetryAfter > 0 {
+		return statusErr.RetryAfter
+	}
+
+	base := p.BaseDelay
+	if base <= 0 {
+		base = defaultRetryBaseDelay
+	}
+	maxDelay := p.MaxDelay
+	if maxDelay <= 0 {
+		maxDelay = defaultRetryMaxDelay
+	}
+
+	delay := base << attempt
+	if delay <= 0 || delay > maxDelay {
+		delay = maxDelay
+	}
+
+	// Jitter between half and the full delay so parallel chunk requests do not retry in lockstep.
+	return delay/2 + rand.N(delay/2+1)
+}
+
+// Do sends a request with do until it succeeds, fails permanently or runs out of retries,
+// waiting with exponential backoff in between. Each retry is reported on warnings, as a
+// request to server.
+func (p RetryPolicy) Do(ctx context.Context, warnings io.Writer, server string, do func() (*http.Response, error)) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		resp, err := do()
+		if err == nil {
+			return resp, nil
+		}
+
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if attempt >= p.MaxRetries || !IsRetryable(err) {
+			return nil, err
+		}
+
+		delay := p.delay(attempt, err)
+		fmt.Fprintf(warnings, "Warning: %s request failed (%v), retrying in %s (%d/%d)\n", server, err, delay.Round(time.Millisecond), attempt+1, p.MaxRetries)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package llm
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/brognilucas/ai-changelog/internal/git"
+)
+
+var (
+	terminalEscape = regexp.MustCompile(`\x1b\[[0-9;?]*[ -/]*[@-~]|\x1b\][^\x07\x1b]*(?:\x07|\x1b\\)`)
+	// Tags that delimit data blocks or chat roles; commit text must not be able to close a block.
+	promptTag = regexp.MustCompile(`(?i)<\s*/?\s*(?:commits|breaking-changes|drafts?|examples?|system|user|assistant|instructions?)\b[^<>]*>`)
+)
+
+// SanitizeText removes what could hide or forge prompt structure in commit data: terminal
+// escapes, control and invisible formatting characters (zero-width and bidi overrides), and
+// the tags that delimit the data blocks, whose angle brackets are swapped for ‹ and ›.
+func SanitizeText(text string) string {
+	text = terminalEscape.ReplaceAllString(text, "")
+	text = strings.Map(func(r rune) rune {
+		switch {
+		case r == '\n' || r == '\t':
+			return r
+		case unicode.IsControl(r) || unicode.Is(unicode.Cf, r):
+			return -1
+		}
+		return r
+	}, text)
+
+	return promptTag.ReplaceAllStringFunc(text, func(tag string) string {
+		return "‹" + tag[1:len(tag)-1] + "›"
+	})
+}
+
+func sanitizeLine(text string) string {
+	return strings.Join(strings.Fields(SanitizeText(text)), " ")
+}
+
+// SanitizeCommits returns copies of the commits that are safe to place in a prompt. Subjects
+// and other one-line fields are collapsed to a single line so they cannot start a new entry.
+func SanitizeCommits(commits []git.Commit) []git.Commit {
+	sanitized := make([]git.Commit, len(commits))
+
+	for i, commit := range commits {
+		commit.Subject = sanitizeLine(commit.Subject)
+		commit.Scope = sanitizeLine(commit.Scope)
+		commit.Body = SanitizeText(commit.Body)
+		commit.BreakingDescription = sanitizeLine(commit.BreakingDescription)
+		if commit.Trailers != nil {
+			trailers := make(map[string][]string, len(commit.Trailers))
+			for key, values := range commit.Trailers {
+				for _, value := range values {
+					trailers[sanitizeLine(key)] = append(trailers[sanitizeLine(key)], sanitizeLine(value))
+				}
+			}
+			commit.Trailers = trailers
+		}
+		sanitized[i] = commit
+	}
+
+	return sanitized
+}
@@ -0,0 +1,144 @@
+package llm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+var DefaultSections = []string{"Breaking Changes", "Highlights", "Improvements", "Bug Fixes"}
+
+var ErrInvalidStructuredOutput = errors.New("invalid structured changelog")
+
+// StructuredChangelog is the JSON document the model returns when a ChangelogRequest has
+// Sections set.
+type StructuredChangelog struct {
+	Summary string            `json:"summary"`
+	Entries []StructuredEntry `json:"entries"`
+}
+
+type StructuredEntry struct {
+	Section string   `json:"section"`
+	Text    string   `json:"text"`
+	Commits []string `json:"commits"`
+}
+
+var commitHashPattern = regexp.MustCompile(`^[0-9a-fA-F]{4,40}$`)
+
+// ChangelogSchema is the JSON schema sent as Ollama's format (and as an OpenAI response_format)
+// so the model can only produce entries in the given sections.
+func ChangelogSchema(sections []string) json.RawMessage {
+	schema := map[string]any{
+		"type":                 "object",
+		"additionalProperties": false,
+		"required":             []string{"summary", "entries"},
+		"properties": map[string]any{
+			"summary": map[string]any{"type": "string"},
+			"entries": map[string]any{
+				"type": "array",
+				"items": map[string]any{
+					"type":                 "object",
+					"additionalProperties": false,
+					"required":             []string{"section", "text", "commits"},
+					"properties": map[string]any{
+						"section": map[string]any{"type": "string", "enum": sections},
+						"text":    map[string]any{"type": "string"},
+						"commits": map[string]any{
+							"type":     "array",
+							"minItems": 1,
+							"items":    map[string]any{"type": "string"},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	data, _ := json.Marshal(schema)
+	return data
+}
+
+func structuredInstructions(sections []string) string {
+	quoted := make([]string, len(sections))
+	for i, section := range sections {
+		quoted[i] = fmt.Sprintf("%q", section)
+	}
+
+	return fmt.Sprintf(`
+Respond with JSON only, in this shape (it replaces any Markdown output format above):
+{"summary": "One-sentence summary of this release.", "entries": [{"section": %s, "text": "One concise line describing the change.", "commits": ["abc1234"]}]}
+"section" must be one of: %s. "commits" lists the short hashes of the commits each entry is based on.
+`, quoted[0], strings.Join(quoted, ", "))
+}
+
+// ParseStructuredChangelog decodes and validates a structured response. Anything outside the
+// schema (unknown sections, multi-line entries, trailing commentary) is rejected, so the
+// caller can fall back instead of publishing it.
+func ParseStructuredChangelog(response string, sections []string) (StructuredChangelog, error) {
+	var result StructuredChangelog
+
+	decoder := json.NewDecoder(strings.NewReader(stripCodeFence(response)))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&result); err != nil {
+		return result, fmt.Errorf("%w: %v", ErrInvalidStructuredOutput, err)
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return result, fmt.Errorf("%w: unexpected content after the JSON document", ErrInvalidStructuredOutput)
+	}
+
+	if len(result.Entries) == 0 {
+		return result, fmt.Errorf("%w: no entries", ErrInvalidStructuredOutput)
+	}
+
+	allowed := make(map[string]bool, len(sections))
+	for _, section := range sections {
+		allowed[section] = true
+	}
+
+	result.Summary = strings.TrimSpace(result.Summary)
+	if strings.Contains(result.Summary, "\n") {
+		return result, fmt.Errorf("%w: summary spans several lines", ErrInvalidStructuredOutput)
+	}
+
+	for i := range result.Entries {
+		entry := &result.Entries[i]
+		entry.Text = strings.TrimSpace(entry.Text)
+
+		switch {
+		case !allowed[entry.Section]:
+			return result, fmt.Errorf("%w: entry %d has unknown section %q", ErrInvalidStructuredOutput, i+1, entry.Section)
+		case entry.Text == "":
+			return result, fmt.Errorf("%w: entry %d is empty", ErrInvalidStructuredOutput, i+1)
+		case strings.Contains(entry.Text, "\n"):
+			return result, fmt.Errorf("%w: entry %d spans several lines", ErrInvalidStructuredOutput, i+1)
+		case len(entry.Commits) == 0:
+			return result, fmt.Errorf("%w: entry %d cites no commits", ErrInvalidStructuredOutput, i+1)
+		}
+
+		for _, hash := range entry.Commits {
+			if !commitHashPattern.MatchString(hash) {
+				return result, fmt.Errorf("%w: entry %d cites %q, which is not a commit hash", ErrInvalidStructuredOutput, i+1, hash)
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// stripCodeFence removes a Markdown code fence around the whole response, which some models
+// add even when asked for JSON.
+func stripCodeFence(response string) string {
+	trimmed := strings.TrimSpace(response)
+	if !strings.HasPrefix(trimmed, "```") || !strings.HasSuffix(trimmed, "```") {
+		return trimmed
+	}
+
+	trimmed = strings.TrimSuffix(trimmed, "```")
+	if newline := strings.Index(trimmed, "\n"); newline != -1 {
+		trimmed = trimmed[newline+1:]
+	}
+	return strings.TrimSpace(trimmed)
+}
@@ -0,0 +1,80 @@
+package ollama
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/brognilucas/ai-changelog/internal/llm"
+)
+
+// APIs a DefaultClient can write changelogs with. The chat API receives the prompt rules as a
+// system message, apart from the commit data; generate remains for models without a chat template.
+const (
+	APIGenerate = "generate"
+	APIChat     = "chat"
+)
+
+type ChatRequest struct {
+	Model     string            `json:"model"`
+	Messages  []llm.Message     `json:"messages"`
+	Stream    bool              `json:"stream"`
+	KeepAlive string            `json:"keep_alive,omitempty"`
+	Options   *llm.ModelOptions `json:"options,omitempty"`
+	Format    json.RawMessage   `json:"format,omitempty"`
+}
+
+type ChatResponse struct {
+	Model   string      `json:"model"`
+	Message llm.Message `json:"message"`
+	Done    bool        `json:"done"`
+	Error   string      `json:"error,omitempty"`
+}
+
+// withoutTurns drops what only the chat API can send, with a warning.
+func (c *DefaultClient) withoutTurns(request llm.ChangelogRequest) llm.ChangelogRequest {
+	if len(request.Examples) > 0 || len(request.Refinements) > 0 {
+		fmt.Fprintf(c.warnings, "Warning: few-shot examples and refinements need the %s API, ignoring them\n", APIChat)
+		request.Examples, request.Refinements = nil, nil
+	}
+	return request
+}
+
+func (c *DefaultClient) chat(ctx context.Context, request ChatRequest) (string, error) {
+	resp, err := c.postChat(ctx, request)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var response ChatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return "", fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return response.Message.Content, nil
+}
+
+func (c *DefaultClient) chatStream(ctx context.Context, request ChatRequest, onToken func(string)) (string, error) {
+	request.Stream = true
+	resp, err := c.postChat(ctx, request)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	return readStream(resp.Body, onToken, func(line []byte) (streamChunk, error) {
+		var chunk ChatResponse
+		if err := json.Unmarshal(line, &chunk); err != nil {
+			return streamChunk{}, err
+		}
+		return streamChunk{Text: chunk.Message.Content, Done: chunk.Done, Error: chunk.Error}, nil
+	})
+}
+
+func (c *DefaultClient) postChat(ctx context.Context, request ChatRequest) (*http.Response, error) {
+	request.KeepAlive = c.keepAlive
+	request.Options = c.modelOptions
+	return c.postJSON(ctx, c.httpClient, "/api/chat", request)
+}
@@ -2,56 +2,68 @@ package ollama
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
-	"strings"
 	"time"
 
 	"github.com/brognilucas/ai-changelog/internal/git"
+	"github.com/brognilucas/ai-changelog/internal/llm"
 )
 
-type Client interface {
-	HealthCheck() error
-	SummarizeCommits(commits []git.Commit, model string) ([]string, error)
-	GenerateChangelog(commits []git.Commit, model string) (string, error)
-}
-
 type GenerateRequest struct {
-	Model  string `json:"model"`
-	Prompt string `json:"prompt"`
-	Stream bool   `json:"stream"`
+	Model     string            `json:"model"`
+	Prompt    string            `json:"prompt"`
+	Stream    bool              `json:"stream"`
+	KeepAlive string            `json:"keep_alive,omitempty"`
+	Options   *llm.ModelOptions `json:"options,omitempty"`
+	Format    json.RawMessage   `json:"format,omitempty"`
 }
 
 type GenerateResponse struct {
 	Model    string `json:"model"`
 	Response string `json:"response"`
 	Done     bool   `json:"done"`
+	Error    string `json:"error,omitempty"`
 }
 
 type DefaultClient struct {
-	baseURL    string
-	httpClient *http.Client
+	baseURL       string
+	api           string
+	httpClient    *http.Client
+	contextLength int
+	keepAlive     string
+	modelOptions  *llm.ModelOptions
+	retry         llm.RetryPolicy
+	warnings      io.Writer
 }
 
-const defaultTimeout = 30 * time.Second
+const healthCheckTimeout = 5 * time.Second
 
 func NewDefaultClient(baseURL string) *DefaultClient {
-	return &DefaultClient{
-		baseURL:    baseURL,
-		httpClient: &http.Client{Timeout: defaultTimeout},
-	}
+	return NewDefaultClientWithTimeout(baseURL, llm.DefaultTimeout)
 }
 
 func NewDefaultClientWithTimeout(baseURL string, timeout time.Duration) *DefaultClient {
 	return &DefaultClient{
 		baseURL:    baseURL,
 		httpClient: &http.Client{Timeout: timeout},
+		warnings:   io.Discard,
 	}
 }
 
-func (c *DefaultClient) HealthCheck() error {
-	resp, err := c.httpClient.Get(c.baseURL)
+func (c *DefaultClient) HealthCheck(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		return fmt.Errorf("ollama not reachable: %w", err)
 	}
@@ -66,7 +78,7 @@ func (c *DefaultClient) HealthCheck() error {
 
 const defaultBatchSize = 10
 
-func (c *DefaultClient) SummarizeCommits(commits []git.Commit, model string) ([]string, error) {
+func (c *DefaultClient) SummarizeCommits(ctx context.Context, commits []git.Commit, model string) ([]string, error) {
 	if len(commits) == 0 {
 		return []string{}, nil
 	}
@@ -80,10 +92,13 @@ func (c *DefaultClient) SummarizeCommits(commits []git.Commit, model string) ([]
 		}
 
 		batch := commits[i:end]
-		prompt := BuildPrompt(batch)
+		prompt := llm.BuildPrompt(batch)
 
-		response, err := c.Generate(model, prompt)
+		response, err := c.Generate(ctx, model, prompt)
 		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
 			for _, commit := range batch {
 				summaries = append(summaries, commit.Subject)
 			}
@@ -96,28 +111,25 @@ func (c *DefaultClient) SummarizeCommits(commits []git.Commit, model string) ([]
 	return summaries, nil
 }
 
-func (c *DefaultClient) Generate(model string, prompt string) (string, error) {
-	request := GenerateRequest{
-		Model:  model,
-		Prompt: prompt,
-		Stream: false,
-	}
-
-	body, err := json.Marshal(request)
+func (c *DefaultClient) Generate(ctx context.Context, model string, prompt string) (string, error) {
+	response, err := c.generate(ctx, model, prompt)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal request: %w", err)
+		return "", fmt.Errorf("generate request failed: %w", err)
 	}
+	return response, nil
+}
+
+func (c *DefaultClient) generate(ctx context.Context, model string, prompt string) (string, error) {
+	return c.generateRequest(ctx, GenerateRequest{Model: model, Prompt: prompt, Stream: false})
+}
 
-	resp, err := c.httpClient.Post(c.baseURL+"/api/generate", "application/json", bytes.NewReader(body))
+func (c *DefaultClient) generateRequest(ctx context.Context, request GenerateRequest) (string, error) {
+	resp, err := c.postGenerate(ctx, request)
 	if err != nil {
-		return "", fmt.Errorf("generate request failed: %w", err)
+		return "", err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("ollama returned status %d", resp.StatusCode)
-	}
-
 	var response GenerateResponse
 	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
 		return "", fmt.Errorf("failed to decode response: %w", err)
@@ -126,114 +138,67 @@ func (c *DefaultClient) Generate(model string, prompt string) (string, error) {
 	return response.Response, nil
 }
 
-const changelogTimeout = 120 * time.Second
-
-func (c *DefaultClient) GenerateChangelog(commits []git.Commit, model string) (string, error) {
-	if len(commits) == 0 {
-		return "", nil
-	}
-
-	prompt := BuildChangelogPrompt(commits)
-
-	request := GenerateRequest{
-		Model:  model,
-		Prompt: prompt,
-		Stream: false,
-	}
+func (c *DefaultClient) postGenerate(ctx context.Context, request GenerateRequest) (*http.Response, error) {
+	request.KeepAlive = c.keepAlive
+	request.Options = c.modelOptions
+	return c.postJSON(ctx, c.httpClient, "/api/generate", request)
+}
 
+func (c *DefaultClient) postJSON(ctx context.Context, httpClient *http.Client, path string, request any) (*http.Response, error) {
 	body, err := json.Marshal(request)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal request: %w", err)
-	}
-
-	httpClient := &http.Client{Timeout: changelogTimeout}
-
-	resp, err := httpClient.Post(c.baseURL+"/api/generate", "application/json", bytes.NewReader(body))
-	if err != nil {
-		return "", fmt.Errorf("changelog generation failed: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("ollama returned status %d", resp.StatusCode)
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	var response GenerateResponse
-	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
-		return "", fmt.Errorf("failed to decode response: %w", err)
-	}
-
-	return response.Response, nil
+	return c.send(ctx, httpClient, http.MethodPost, path, body)
 }
 
-func BuildChangelogPrompt(commits []git.Commit) string {
-	if len(commits) == 0 {
-		return ""
-	}
-
-	var builder strings.Builder
-
-	builder.WriteString(`You are a professional release notes writer. Given the git commits below, produce a clean changelog in Markdown.
-
-Rules:
-1. Collapse related commits (e.g. "add struct", "add interface", "implement method") into ONE high-level entry describing the user-facing capability.
-2. Write from the user's perspective — describe what users can now DO, not what code artifacts were created.
-3. Omit commits that are purely tests, refactoring, style changes, or internal restructuring. Users do not care about these.
-4. Order entries by importance (most impactful first), NOT chronologically.
-5. Start with a single-sentence summary of the overall release.
-6. Use exactly these sections (skip a section if no entries fit it):
-   - **Highlights** — major new capabilities
-   - **Improvements** — enhancements to existing functionality
-   - **Bug Fixes** — resolved issues
-7. Each entry should be one concise line starting with "- ".
-8. Do NOT include commit hashes, author names, or dates in entries.
-9. Do NOT add any explanation or commentary outside the changelog format.
-10. Do NOT wrap the output in a code block.
-
-Output format:
-
-_One-sentence summary of this release._
-
-## Highlights
-
-- Entry here
-
-## Improvements
-
-- Entry here
-
-## Bug Fixes
-
-- Entry here
+// send performs the request with retries and turns non-200 responses into a StatusError.
+func (c *DefaultClient) send(ctx context.Context, httpClient *http.Client, method string, path string, body []byte) (*http.Response, error) {
+	return c.withRetry(ctx, func() (*http.Response, error) {
+		req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, bytes.NewReader(body))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+		if body != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
 
-Commits:
-`)
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
 
-	for _, commit := range commits {
-		hash := commit.Hash
-		if len(hash) > 7 {
-			hash = hash[:7]
+		if resp.StatusCode != http.StatusOK {
+			defer resp.Body.Close()
+			return nil, llm.NewStatusError("ollama", resp)
 		}
-		builder.WriteString(fmt.Sprintf("- %s (%s)\n", commit.Subject, hash))
-	}
 
-	return builder.String()
+		return resp, nil
+	})
 }
 
-func BuildPrompt(commits []git.Commit) string {
-	if len(commits) == 0 {
-		return ""
+func (c *DefaultClient) GenerateChangelog(ctx context.Context, request llm.ChangelogRequest) (string, error) {
+	if len(request.Commits) == 0 {
+		return "", nil
 	}
 
-	var builder strings.Builder
-	builder.WriteString("You are a changelog generator. Summarize the following git commits into clear, user-friendly changelog entries.\n\n")
-	builder.WriteString("Commits:\n")
-
-	for _, commit := range commits {
-		builder.WriteString(fmt.Sprintf("- %s\n", commit.Subject))
+	var response string
+	var err error
+	if c.api == APIChat {
+		send := func(ctx context.Context, messages llm.Messages) (string, error) {
+			return c.chat(ctx, ChatRequest{Model: request.Model, Messages: llm.ChatMessages(messages), Format: request.Format()})
+		}
+		response, err = llm.ChatChangelog(ctx, request, c.contextLength, send, send)
+	} else {
+		request = c.withoutTurns(request)
+		response, err = llm.MapReduceChangelog(ctx, request, c.contextLength, func(ctx context.Context, prompt string) (string, error) {
+			return c.generateRequest(ctx, GenerateRequest{Model: request.Model, Prompt: prompt, Format: request.Format()})
+		})
+	}
+	if err != nil {
+		return "", fmt.Errorf("changelog generation failed: %w", err)
 	}
 
-	builder.WriteString("\nGenerate a concise changelog summary grouped by type (features, fixes, etc.).")
-
-	return builder.String()
-}
\ No newline at end of file
+	return response, nil
+}
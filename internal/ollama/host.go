@@ -0,0 +1,52 @@
+package ollama
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+)
+
+const defaultPort = "11434"
+
+// ParseHost accepts the same forms as the OLLAMA_HOST environment variable:
+// a full URL, a bare host:port, a bare host or just :port.
+func ParseHost(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", errors.New("empty ollama host")
+	}
+
+	scheme, hostport, found := strings.Cut(value, "://")
+	if !found {
+		scheme, hostport = "http", value
+	} else if scheme != "http" && scheme != "https" {
+		return "", fmt.Errorf("unsupported scheme %q in ollama host %q", scheme, value)
+	}
+
+	hostport, path, _ := strings.Cut(hostport, "/")
+
+	host, port, err := net.SplitHostPort(hostport)
+	if err != nil {
+		host, port = strings.Trim(hostport, "[]"), ""
+	}
+	if host == "" {
+		host = "127.0.0.1"
+	}
+	if port == "" && !found {
+		port = defaultPort
+	}
+
+	parsed := url.URL{Scheme: scheme, Host: host}
+	if port != "" {
+		parsed.Host = net.JoinHostPort(host, port)
+	} else if strings.Contains(host, ":") {
+		parsed.Host = "[" + host + "]"
+	}
+	if path = strings.Trim(path, "/"); path != "" {
+		parsed.Path = "/" + path
+	}
+
+	return parsed.String(), nil
+}
@@ -0,0 +1,178 @@
+package ollama
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+var ErrModelNotFound = errors.New("model not found")
+
+type Model struct {
+	Name       string       `json:"name"`
+	Size       int64        `json:"size"`
+	ModifiedAt time.Time    `json:"modified_at"`
+	Details    ModelDetails `json:"details"`
+}
+
+type ModelDetails struct {
+	Family            string `json:"family"`
+	ParameterSize     string `json:"parameter_size"`
+	QuantizationLevel string `json:"quantization_level"`
+}
+
+type PullProgress struct {
+	Status    string `json:"status"`
+	Digest    string `json:"digest,omitempty"`
+	Total     int64  `json:"total,omitempty"`
+	Completed int64  `json:"completed,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+func (c *DefaultClient) ListModels(ctx context.Context) ([]Model, error) {
+	resp, err := c.send(ctx, c.httpClient, http.MethodGet, "/api/tags", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list models: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var response struct {
+		Models []Model `json:"models"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("failed to decode model list: %w", err)
+	}
+
+	return response.Models, nil
+}
+
+// ContextLength reads the model's context window from /api/show. It returns 0 when the
+// model does not report one.
+func (c *DefaultClient) ContextLength(ctx context.Context, model string) (int, error) {
+	resp, err := c.postJSON(ctx, c.httpClient, "/api/show", map[string]string{"model": model})
+	if err != nil {
+		return 0, fmt.Errorf("failed to show model %s: %w", model, err)
+	}
+	defer resp.Body.Close()
+
+	var response struct {
+		ModelInfo map[string]any `json:"model_info"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return 0, fmt.Errorf("failed to decode model info: %w", err)
+	}
+
+	// The key is prefixed with the architecture, e.g. "llama.context_length".
+	for key, value := range response.ModelInfo {
+		if length, ok := value.(float64); ok && strings.HasSuffix(key, ".context_length") {
+			return int(length), nil
+		}
+	}
+	return 0, nil
+}
+
+// CheckModel returns ErrModelNotFound when the model has not been pulled to the server.
+func (c *DefaultClient) CheckModel(ctx context.Context, model string) error {
+	models, err := c.ListModels(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, available := range models {
+		if modelMatches(available.Name, model) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrModelNotFound, model)
+}
+
+// modelMatches compares model names the way Ollama resolves them: a name without a tag
+// refers to the "latest" tag.
+func modelMatches(name string, model string) bool {
+	return withDefaultTag(name) == withDefaultTag(model)
+}
+
+func withDefaultTag(model string) string {
+	if strings.Contains(model[strings.LastIndex(model, "/")+1:], ":") {
+		return model
+	}
+	return model + ":latest"
+}
+
+// PullModel downloads the model through /api/pull, writing progress lines to progress.
+func (c *DefaultClient) PullModel(ctx context.Context, model string, progress io.Writer) error {
+	// Downloads take far longer than a generate request, so only ctx limits the pull.
+	httpClient := &http.Client{Transport: c.httpClient.Transport}
+
+	resp, err := c.postJSON(ctx, httpClient, "/api/pull", map[string]any{"model": model, "stream": true})
+	if err != nil {
+		return fmt.Errorf("failed to pull model %s: %w", model, err)
+	}
+	defer resp.Body.Close()
+
+	reporter := &pullReporter{writer: progress}
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxStreamLineSize)
+
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var update PullProgress
+		if err := json.Unmarshal(line, &update); err != nil {
+			return fmt.Errorf("failed to decode pull progress: %w", err)
+		}
+		if update.Error != "" {
+			return fmt.Errorf("failed to pull model %s: %s", model, update.Error)
+		}
+
+		reporter.report(update)
+		if update.Status == "success" {
+			return nil
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to pull model %s: %w", model, err)
+	}
+	return fmt.Errorf("failed to pull model %s: %w", model, ErrStreamInterrupted)
+}
+
+// pullReporter prints a line when the status changes and every 10% of a download, so the
+// output stays readable in CI logs as well as terminals.
+type pullReporter struct {
+	writer  io.Writer
+	status  string
+	percent int64
+}
+
+func (r *pullReporter) report(update PullProgress) {
+	if r.writer == nil {
+		return
+	}
+
+	if update.Status != r.status {
+		r.status = update.Status
+		r.percent = -1
+		if update.Total == 0 {
+			fmt.Fprintln(r.writer, update.Status)
+		}
+	}
+
+	if update.Total > 0 {
+		percent := update.Completed * 100 / update.Total / 10 * 10
+		if percent > r.percent {
+			r.percent = percent
+			fmt.Fprintf(r.writer, "%s: %d%%\n", update.Status, percent)
+		}
+	}
+}
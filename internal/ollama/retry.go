@@ -0,0 +1,22 @@
+package ollama
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+func (c *DefaultClient) withRetry(ctx context.Context, do func() (*http.Response, error)) (*http.Response, error) {
+	return c.retry.Do(ctx, c.warnings, "ollama", do)
+}
+
+// WarmUp loads the model into memory with an empty prompt, so that the first real request
+// does not time out while Ollama is still loading it.
+func (c *DefaultClient) WarmUp(ctx context.Context, model string) error {
+	resp, err := c.postGenerate(ctx, GenerateRequest{Model: model, Stream: false})
+	if err != nil {
+		return fmt.Errorf("failed to load model %s: %w", model, err)
+	}
+	resp.Body.Close()
+	return nil
+}
@@ -0,0 +1,113 @@
+package ollama
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/brognilucas/ai-changelog/internal/llm"
+)
+
+var ErrStreamInterrupted = errors.New("stream ended before the response was complete")
+
+const maxStreamLineSize = 1024 * 1024
+
+func (c *DefaultClient) GenerateChangelogStream(ctx context.Context, request llm.ChangelogRequest, onToken func(string)) (string, error) {
+	if len(request.Commits) == 0 {
+		return "", nil
+	}
+
+	var response string
+	var err error
+	if c.api == APIChat {
+		response, err = llm.ChatChangelog(ctx, request, c.contextLength,
+			func(ctx context.Context, messages llm.Messages) (string, error) {
+				return c.chat(ctx, ChatRequest{Model: request.Model, Messages: llm.ChatMessages(messages)})
+			},
+			func(ctx context.Context, messages llm.Messages) (string, error) {
+				return c.chatStream(ctx, ChatRequest{Model: request.Model, Messages: llm.ChatMessages(messages)}, onToken)
+			},
+		)
+	} else {
+		request = c.withoutTurns(request)
+		response, err = llm.MapReduceChangelogWithFinal(ctx, request, c.contextLength,
+			func(ctx context.Context, prompt string) (string, error) {
+				return c.generate(ctx, request.Model, prompt)
+			},
+			func(ctx context.Context, prompt string) (string, error) {
+				return c.generateStream(ctx, request.Model, prompt, onToken)
+			},
+		)
+	}
+	if err != nil {
+		return response, fmt.Errorf("changelog generation failed: %w", err)
+	}
+
+	return response, nil
+}
+
+// generateStream reads Ollama's NDJSON stream, passing each token to onToken. On failure it
+// returns the text received so far together with the error.
+func (c *DefaultClient) generateStream(ctx context.Context, model string, prompt string, onToken func(string)) (string, error) {
+	resp, err := c.postGenerate(ctx, GenerateRequest{Model: model, Prompt: prompt, Stream: true})
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	return readStream(resp.Body, onToken, func(line []byte) (streamChunk, error) {
+		var chunk GenerateResponse
+		if err := json.Unmarshal(line, &chunk); err != nil {
+			return streamChunk{}, err
+		}
+		return streamChunk{Text: chunk.Response, Done: chunk.Done, Error: chunk.Error}, nil
+	})
+}
+
+// streamChunk is one line of a generate or chat stream.
+type streamChunk struct {
+	Text  string
+	Done  bool
+	Error string
+}
+
+func readStream(body io.Reader, onToken func(string), decode func(line []byte) (streamChunk, error)) (string, error) {
+	var text bytes.Buffer
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxStreamLineSize)
+
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		chunk, err := decode(line)
+		if err != nil {
+			return text.String(), fmt.Errorf("failed to decode stream chunk: %w", err)
+		}
+		if chunk.Error != "" {
+			return text.String(), fmt.Errorf("ollama stream error: %s", chunk.Error)
+		}
+
+		if chunk.Text != "" {
+			text.WriteString(chunk.Text)
+			if onToken != nil {
+				onToken(chunk.Text)
+			}
+		}
+
+		if chunk.Done {
+			return text.String(), nil
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return text.String(), fmt.Errorf("%w: %v", ErrStreamInterrupted, err)
+	}
+	return text.String(), ErrStreamInterrupted
+}
@@ -0,0 +1,113 @@
+package ollama
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/brognilucas/ai-changelog/internal/llm"
+)
+
+// ClientOptions configures NewDefaultClientWithOptions. API selects APIChat or APIGenerate;
+// empty uses the generate API.
+type ClientOptions struct {
+	API           string
+	Timeout       time.Duration
+	ContextLength int
+	BearerToken   string
+	Username      string
+	Password      string
+	CAFile        string
+	KeepAlive     string
+	ModelOptions  *llm.ModelOptions
+	Retry         llm.RetryPolicy
+	Warnings      io.Writer
+}
+
+func NewDefaultClientWithOptions(baseURL string, options ClientOptions) (*DefaultClient, error) {
+	if options.BearerToken != "" && options.Username != "" {
+		return nil, errors.New("use either a bearer token or basic auth, not both")
+	}
+
+	timeout := options.Timeout
+	if timeout == 0 {
+		timeout = llm.DefaultTimeout
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if options.CAFile != "" {
+		pool, err := loadCertPool(options.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+	}
+
+	warnings := options.Warnings
+	if warnings == nil {
+		warnings = os.Stderr
+	}
+
+	return &DefaultClient{
+		baseURL:       baseURL,
+		api:           options.API,
+		contextLength: options.ContextLength,
+		keepAlive:     options.KeepAlive,
+		modelOptions:  options.ModelOptions,
+		retry:         options.Retry,
+		warnings:      warnings,
+		httpClient: &http.Client{
+			Timeout: timeout,
+			Transport: &authTransport{
+				base:        transport,
+				bearerToken: options.BearerToken,
+				username:    options.Username,
+				password:    options.Password,
+			},
+		},
+	}, nil
+}
+
+func loadCertPool(path string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in CA bundle %s", path)
+	}
+
+	return pool, nil
+}
+
+type authTransport struct {
+	base        http.RoundTripper
+	bearerToken string
+	username    string
+	password    string
+}
+
+func (t *authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.bearerToken == "" && t.username == "" {
+		return t.base.RoundTrip(req)
+	}
+
+	req = req.Clone(req.Context())
+	if t.bearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+t.bearerToken)
+	} else {
+		req.SetBasicAuth(t.username, t.password)
+	}
+
+	return t.base.RoundTrip(req)
+}
@@ -0,0 +1,261 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/brognilucas/ai-changelog/internal/git"
+	"github.com/brognilucas/ai-changelog/internal/llm"
+)
+
+type ChatCompletionRequest struct {
+	Model       string        `json:"model"`
+	Messages    []llm.Message `json:"messages"`
+	Stream      bool          `json:"stream"`
+	Temperature *float64      `json:"temperature,omitempty"`
+	TopP        *float64      `json:"top_p,omitempty"`
+	Seed        *int          `json:"seed,omitempty"`
+	MaxTokens   int           `json:"max_tokens,omitempty"`
+
+	ResponseFormat *ResponseFormat `json:"response_format,omitempty"`
+}
+
+type ResponseFormat struct {
+	Type       string      `json:"type"`
+	JSONSchema *JSONSchema `json:"json_schema,omitempty"`
+}
+
+type JSONSchema struct {
+	Name   string          `json:"name"`
+	Schema json.RawMessage `json:"schema"`
+}
+
+type ChatCompletionChoice struct {
+	Message      llm.Message `json:"message"`
+	FinishReason string      `json:"finish_reason"`
+}
+
+type ChatCompletionResponse struct {
+	Model   string                 `json:"model"`
+	Choices []ChatCompletionChoice `json:"choices"`
+}
+
+type DefaultClient struct {
+	baseURL       string
+	apiKey        string
+	httpClient    *http.Client
+	contextLength int
+	modelOptions  *llm.ModelOptions
+	retry         llm.RetryPolicy
+	warnings      io.Writer
+}
+
+type ClientOptions struct {
+	APIKey        string
+	ContextLength int
+	Timeout       time.Duration
+	ModelOptions  *llm.ModelOptions
+	Retry         llm.RetryPolicy
+	Warnings      io.Writer
+}
+
+const serverName = "openai-compatible server"
+
+const healthCheckTimeout = 5 * time.Second
+
+func NewDefaultClient(baseURL string) *DefaultClient {
+	return NewDefaultClientWithAPIKey(baseURL, "")
+}
+
+func NewDefaultClientWithAPIKey(baseURL string, apiKey string) *DefaultClient {
+	return NewDefaultClientWithOptions(baseURL, ClientOptions{APIKey: apiKey})
+}
+
+func NewDefaultClientWithOptions(baseURL string, options ClientOptions) *DefaultClient {
+	timeout := options.Timeout
+	if timeout == 0 {
+		timeout = llm.DefaultTimeout
+	}
+
+	warnings := options.Warnings
+	if warnings == nil {
+		warnings = os.Stderr
+	}
+
+	return &DefaultClient{
+		baseURL:       apiBaseURL(baseURL),
+		apiKey:        options.APIKey,
+		httpClient:    &http.Client{Timeout: timeout},
+		contextLength: options.ContextLength,
+		modelOptions:  options.ModelOptions,
+		retry:         options.Retry,
+		warnings:      warnings,
+	}
+}
+
+func NewDefaultClientWithTimeout(baseURL string, timeout time.Duration) *DefaultClient {
+	return NewDefaultClientWithOptions(baseURL, ClientOptions{Timeout: timeout})
+}
+
+// OpenAI-compatible servers are configured either with or without the /v1 suffix.
+func apiBaseURL(baseURL string) string {
+	return strings.TrimSuffix(strings.TrimSuffix(baseURL, "/"), "/v1") + "/v1"
+}
+
+func (c *DefaultClient) HealthCheck(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	req, err := c.newRequest(ctx, http.MethodGet, "/models", nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s not reachable: %w", serverName, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned status %d", serverName, resp.StatusCode)
+	}
+
+	return nil
+}
+
+const defaultBatchSize = 10
+
+func (c *DefaultClient) SummarizeCommits(ctx context.Context, commits []git.Commit, model string) ([]string, error) {
+	if len(commits) == 0 {
+		return []string{}, nil
+	}
+
+	var summaries []string
+
+	for i := 0; i < len(commits); i += defaultBatchSize {
+		end := i + defaultBatchSize
+		if end > len(commits) {
+			end = len(commits)
+		}
+
+		batch := commits[i:end]
+
+		response, err := c.Generate(ctx, model, llm.BuildPrompt(batch))
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			for _, commit := range batch {
+				summaries = append(summaries, commit.Subject)
+			}
+			continue
+		}
+
+		summaries = append(summaries, response)
+	}
+
+	return summaries, nil
+}
+
+func (c *DefaultClient) Generate(ctx context.Context, model string, prompt string) (string, error) {
+	return c.complete(ctx, model, []llm.Message{{Role: llm.RoleUser, Content: prompt}}, nil)
+}
+
+func (c *DefaultClient) GenerateChangelog(ctx context.Context, request llm.ChangelogRequest) (string, error) {
+	if len(request.Commits) == 0 {
+		return "", nil
+	}
+
+	send := func(ctx context.Context, messages llm.Messages) (string, error) {
+		return c.complete(ctx, request.Model, llm.ChatMessages(messages), request.Format())
+	}
+	response, err := llm.ChatChangelog(ctx, request, c.contextLength, send, send)
+	if err != nil {
+		return "", fmt.Errorf("changelog generation failed: %w", err)
+	}
+
+	return response, nil
+}
+
+func (c *DefaultClient) complete(ctx context.Context, model string, messages []llm.Message, schema json.RawMessage) (string, error) {
+	request := ChatCompletionRequest{
+		Model:    model,
+		Messages: messages,
+		Stream:   false,
+	}
+	if schema != nil {
+		request.ResponseFormat = &ResponseFormat{
+			Type:       "json_schema",
+			JSONSchema: &JSONSchema{Name: "changelog", Schema: schema},
+		}
+	}
+	// num_ctx has no chat completions equivalent; the server decides the context window.
+	if options := c.modelOptions; options != nil {
+		request.Temperature = &options.Temperature
+		request.TopP = &options.TopP
+		request.Seed = &options.Seed
+		request.MaxTokens = options.NumPredict
+	}
+
+	body, err := json.Marshal(request)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	resp, err := c.retry.Do(ctx, c.warnings, serverName, func() (*http.Response, error) {
+		req, err := c.newRequest(ctx, http.MethodPost, "/chat/completions", bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			defer resp.Body.Close()
+			return nil, llm.NewStatusError(serverName, resp)
+		}
+
+		return resp, nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("chat completion request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var response ChatCompletionResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return "", fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	if len(response.Choices) == 0 {
+		return "", errors.New("response contained no choices")
+	}
+
+	return response.Choices[0].Message.Content, nil
+}
+
+func (c *DefaultClient) newRequest(ctx context.Context, method string, path string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	return req, nil
+}
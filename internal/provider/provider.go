@@ -0,0 +1,105 @@
+package provider
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/brognilucas/ai-changelog/internal/llm"
+	"github.com/brognilucas/ai-changelog/internal/ollama"
+	"github.com/brognilucas/ai-changelog/internal/openai"
+)
+
+const (
+	Ollama = "ollama"
+	OpenAI = "openai"
+)
+
+var defaultEndpoints = map[string]string{
+	Ollama: "http://localhost:11434",
+	OpenAI: "http://localhost:8000/v1",
+}
+
+// Config selects and configures the LLM client. Ollama holds the options only the Ollama
+// client understands; the shared fields override the ones set there.
+type Config struct {
+	Provider      string
+	Endpoint      string
+	ContextLength int
+	Timeout       time.Duration
+	ModelOptions  *llm.ModelOptions
+	Retry         llm.RetryPolicy
+	Ollama        ollama.ClientOptions
+}
+
+func DefaultEndpoint(provider string) string {
+	return defaultEndpoints[provider]
+}
+
+func NewClient(provider string, endpoint string) (llm.Client, error) {
+	return NewClientWithConfig(Config{Provider: provider, Endpoint: endpoint})
+}
+
+func NewClientWithConfig(config Config) (llm.Client, error) {
+	provider := config.Provider
+	if provider == "" {
+		provider = Ollama
+	}
+
+	if _, ok := defaultEndpoints[provider]; !ok {
+		return nil, fmt.Errorf("unknown LLM provider %q (expected %s or %s)", provider, Ollama, OpenAI)
+	}
+
+	endpoint, err := ResolveEndpoint(config)
+	if err != nil {
+		return nil, err
+	}
+
+	switch provider {
+	case OpenAI:
+		return openai.NewDefaultClientWithOptions(endpoint, openai.ClientOptions{
+			APIKey:        os.Getenv("OPENAI_API_KEY"),
+			ContextLength: config.ContextLength,
+			Timeout:       config.Timeout,
+			ModelOptions:  config.ModelOptions,
+			Retry:         config.Retry,
+		}), nil
+	default:
+		options := config.Ollama
+		options.ContextLength = config.ContextLength
+		options.Timeout = config.Timeout
+		options.ModelOptions = config.ModelOptions
+		options.Retry = config.Retry
+		return ollama.NewDefaultClientWithOptions(endpoint, options)
+	}
+}
+
+// ResolveEndpoint is the URL a client for config talks to.
+func ResolveEndpoint(config Config) (string, error) {
+	if config.Provider != OpenAI {
+		return OllamaEndpoint(config.Endpoint)
+	}
+
+	endpoint := config.Endpoint
+	if endpoint == "" {
+		endpoint = DefaultEndpoint(OpenAI)
+	}
+	return strings.TrimSuffix(endpoint, "/"), nil
+}
+
+// OllamaEndpoint resolves the Ollama URL from an explicit value, then OLLAMA_HOST, then the default.
+func OllamaEndpoint(endpoint string) (string, error) {
+	if endpoint == "" {
+		endpoint = os.Getenv("OLLAMA_HOST")
+	}
+	if endpoint == "" {
+		return DefaultEndpoint(Ollama), nil
+	}
+
+	resolved, err := ollama.ParseHost(endpoint)
+	if err != nil {
+		return "", fmt.Errorf("invalid ollama host: %w", err)
+	}
+	return resolved, nil
+}
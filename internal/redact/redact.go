@@ -0,0 +1,156 @@
+package redact
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/brognilucas/ai-changelog/internal/git"
+)
+
+// Kinds of redacted values. Each is replaced by its name in brackets, e.g. "[email]".
+const (
+	KindTicket = "ticket"
+	KindSecret = "secret"
+	KindEmail  = "email"
+	KindHost   = "host"
+	KindCustom = "redacted"
+)
+
+type pattern struct {
+	Kind   string
+	Regexp *regexp.Regexp
+	// Keep is the number of leading capture groups kept in front of the mask, so that
+	// "password=hunter2" becomes "password=[secret]".
+	Keep int
+}
+
+// Ticket URLs go first so an internal tracker host is masked as one ticket, not as a host.
+var defaultPatterns = []pattern{
+	{Kind: KindTicket, Regexp: regexp.MustCompile(`https?://[^\s)>\]]*(?:atlassian\.net|jira|youtrack|linear\.app|/browse/|/issues?/|/tickets?/)[^\s)>\]]*`)},
+	{Kind: KindSecret, Regexp: regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?(?:-----END [A-Z ]*PRIVATE KEY-----|$)`)},
+	{Kind: KindSecret, Regexp: regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{Kind: KindSecret, Regexp: regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})\b`)},
+	{Kind: KindSecret, Regexp: regexp.MustCompile(`\bglpat-[A-Za-z0-9_-]{20,}\b`)},
+	{Kind: KindSecret, Regexp: regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}\b`)},
+	{Kind: KindSecret, Regexp: regexp.MustCompile(`\bsk-[A-Za-z0-9_-]{20,}\b`)},
+	{Kind: KindSecret, Regexp: regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`)},
+	{Kind: KindSecret, Regexp: regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}\b`)},
+	{Kind: KindSecret, Regexp: regexp.MustCompile(`(?i)(\bbearer\s+)[A-Za-z0-9._~+/-]{16,}=*`), Keep: 1},
+	{Kind: KindSecret, Regexp: regexp.MustCompile(`(?i)(\b(?:api[_-]?key|secret|token|passw(?:or)?d|pwd)\s*[:=]\s*)["']?[^\s"',;\[][^\s"',;]{5,}["']?`), Keep: 1},
+	{Kind: KindEmail, Regexp: regexp.MustCompile(`\b[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}\b`)},
+	{Kind: KindHost, Regexp: regexp.MustCompile(`(?i)\b(?:[a-z0-9-]+\.)+(?:internal|corp|local|lan|intranet|localdomain|private)\b`)},
+	{Kind: KindHost, Regexp: regexp.MustCompile(`\b(?:10\.\d{1,3}|192\.168|172\.(?:1[6-9]|2\d|3[01]))\.\d{1,3}\.\d{1,3}\b`)},
+}
+
+// Redactor masks secrets and personal data in commits before they are sent to an LLM.
+type Redactor struct {
+	patterns []pattern
+}
+
+// Redaction records how many values of one kind were masked in a commit.
+type Redaction struct {
+	Hash  string
+	Kind  string
+	Count int
+}
+
+func New() *Redactor {
+	return &Redactor{patterns: defaultPatterns}
+}
+
+// NewWithPatterns adds user-defined regular expressions, masked as "[redacted]", to the
+// default patterns.
+func NewWithPatterns(custom []string) (*Redactor, error) {
+	redactor := &Redactor{patterns: append([]pattern(nil), defaultPatterns...)}
+
+	for _, expression := range custom {
+		compiled, err := regexp.Compile(expression)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse redaction pattern %q: %w", expression, err)
+		}
+		redactor.patterns = append(redactor.patterns, pattern{Kind: KindCustom, Regexp: compiled})
+	}
+
+	return redactor, nil
+}
+
+// String identifies the pattern set, so cached responses are not shared between different sets.
+func (r *Redactor) String() string {
+	expressions := make([]string, len(r.patterns))
+	for i, p := range r.patterns {
+		expressions[i] = p.Regexp.String()
+	}
+	return strings.Join(expressions, "\n")
+}
+
+// Commits returns copies of the commits with every text field the prompt uses masked.
+func (r *Redactor) Commits(commits []git.Commit) ([]git.Commit, []Redaction) {
+	redacted := make([]git.Commit, len(commits))
+	var redactions []Redaction
+
+	for i, commit := range commits {
+		counts := make(map[string]int)
+
+		commit.Subject = r.text(commit.Subject, counts)
+		commit.Body = r.text(commit.Body, counts)
+		commit.BreakingDescription = r.text(commit.BreakingDescription, counts)
+		commit.Scope = r.text(commit.Scope, counts)
+		commit.Author = r.text(commit.Author, counts)
+		if commit.Trailers != nil {
+			trailers := make(map[string][]string, len(commit.Trailers))
+			for _, key := range sortedKeys(commit.Trailers) {
+				values := commit.Trailers[key]
+				key = r.text(key, counts)
+				for _, value := range values {
+					trailers[key] = append(trailers[key], r.text(value, counts))
+				}
+			}
+			commit.Trailers = trailers
+		}
+
+		redacted[i] = commit
+		redactions = append(redactions, sortedRedactions(commit.Hash, counts)...)
+	}
+
+	return redacted, redactions
+}
+
+func (r *Redactor) text(text string, counts map[string]int) string {
+	if text == "" {
+		return text
+	}
+
+	for _, p := range r.patterns {
+		text = p.Regexp.ReplaceAllStringFunc(text, func(match string) string {
+			counts[p.Kind]++
+			mask := "[" + p.Kind + "]"
+			if p.Keep == 0 {
+				return mask
+			}
+			groups := p.Regexp.FindStringSubmatch(match)
+			return strings.Join(groups[1:p.Keep+1], "") + mask
+		})
+	}
+
+	return text
+}
+
+func sortedKeys(trailers map[string][]string) []string {
+	keys := make([]string, 0, len(trailers))
+	for key := range trailers {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortedRedactions(hash string, counts map[string]int) []Redaction {
+	redactions := make([]Redaction, 0, len(counts))
+	for kind, count := range counts {
+		redactions = append(redactions, Redaction{Hash: hash, Kind: kind, Count: count})
+	}
+	sort.Slice(redactions, func(i, j int) bool { return redactions[i].Kind < redactions[j].Kind })
+	return redactions
+}
@@ -1,46 +1,403 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 
 	"github.com/brognilucas/ai-changelog/cmd"
+	"github.com/brognilucas/ai-changelog/internal/cache"
+	"github.com/brognilucas/ai-changelog/internal/config"
 	"github.com/brognilucas/ai-changelog/internal/git"
+	"github.com/brognilucas/ai-changelog/internal/llm"
 	"github.com/brognilucas/ai-changelog/internal/ollama"
+	"github.com/brognilucas/ai-changelog/internal/provider"
+	"github.com/brognilucas/ai-changelog/internal/redact"
 	"github.com/spf13/cobra"
 )
 
 func main() {
 	rootCmd := cmd.NewRootCommand()
+	rootCmd.PersistentPreRunE = func(c *cobra.Command, args []string) error {
+		return applyConfig(c)
+	}
 
 	rootCmd.RunE = func(c *cobra.Command, args []string) error {
-		since, _ := c.Flags().GetString("since")
-		format, _ := c.Flags().GetString("format")
 		output, _ := c.Flags().GetString("output")
-		model, _ := c.Flags().GetString("model")
-		version, _ := c.Flags().GetString("version")
 
-		runner := &git.DefaultRunner{}
-		commitReader := git.NewCommitReader(runner)
-		ollamaClient := ollama.NewDefaultClient("http://localhost:11434")
+		opts, err := readGenerateOptions(c)
+		if err != nil {
+			return err
+		}
+		if opts.Prepend && output == "" {
+			return fmt.Errorf("--prepend requires --output")
+		}
+		if opts.Prepend && opts.Version == "" {
+			return fmt.Errorf("--prepend requires --version")
+		}
 
-		deps := cmd.GenerateDeps{
-			CommitReader: commitReader,
-			OllamaClient: ollamaClient,
+		noStream, _ := c.Flags().GetBool("no-stream")
+		opts.Stream = !noStream && isTerminal(os.Stderr)
+
+		ctx, cancel := commandContext(c)
+		defer cancel()
+
+		deps, err := newGenerateDeps(ctx, c)
+		if err != nil {
+			return err
 		}
 
-		if err := cmd.CheckOllamaHealth(ollamaClient); err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: %v (using raw commit messages)\n", err)
+		if output != "" {
+			return timeoutError(c, cmd.WriteToFile(ctx, deps, opts, output))
+		}
+
+		return timeoutError(c, cmd.RunGenerate(ctx, deps, opts, os.Stdout))
+	}
+
+	historyCmd := cmd.NewHistoryCommand()
+	historyCmd.RunE = func(c *cobra.Command, args []string) error {
+		output, _ := c.Flags().GetString("output")
+
+		ctx, cancel := commandContext(c)
+		defer cancel()
+
+		deps, err := newGenerateDeps(ctx, c)
+		if err != nil {
+			return err
+		}
+		opts, err := readGenerateOptions(c)
+		if err != nil {
+			return err
 		}
 
 		if output != "" {
-			return cmd.WriteToFile(deps, format, since, model, version, output)
+			return timeoutError(c, cmd.WriteHistoryToFile(ctx, deps, opts, output))
+		}
+
+		return timeoutError(c, cmd.RunHistory(ctx, deps, opts, os.Stdout))
+	}
+	rootCmd.AddCommand(historyCmd)
+
+	modelsCmd := cmd.NewModelsCommand()
+	modelsCmd.RunE = func(c *cobra.Command, args []string) error {
+		ctx, cancel := commandContext(c)
+		defer cancel()
+
+		config, err := readLLMConfig(c)
+		if err != nil {
+			return err
+		}
+		if config.Provider != provider.Ollama {
+			return fmt.Errorf("models requires --provider %s", provider.Ollama)
+		}
+
+		client, err := provider.NewClientWithConfig(config)
+		if err != nil {
+			return err
+		}
+		if err := cmd.CheckOllamaHealth(ctx, client); err != nil {
+			return err
+		}
+
+		lister, ok := client.(cmd.ModelLister)
+		if !ok {
+			return fmt.Errorf("%s client cannot list models", config.Provider)
 		}
+		return timeoutError(c, cmd.RunModels(ctx, lister, os.Stdout))
+	}
+	rootCmd.AddCommand(modelsCmd)
 
-		return cmd.RunGenerate(deps, format, since, model, version, os.Stdout)
+	cacheCmd := cmd.NewCacheCommand()
+	clearCmd := cmd.NewCacheClearCommand()
+	clearCmd.RunE = func(c *cobra.Command, args []string) error {
+		dir, err := cache.DefaultDir()
+		if err != nil {
+			return err
+		}
+		return cmd.RunCacheClear(cache.New(dir), os.Stdout)
 	}
+	cacheCmd.AddCommand(clearCmd)
+	rootCmd.AddCommand(cacheCmd)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		// Restore the default handlers so a second Ctrl-C exits immediately.
+		<-ctx.Done()
+		stop()
+	}()
 
-	if err := rootCmd.Execute(); err != nil {
+	if err := rootCmd.ExecuteContext(ctx); err != nil {
+		if ctx.Err() != nil {
+			os.Exit(130)
+		}
 		os.Exit(1)
 	}
 }
+
+func applyConfig(c *cobra.Command) error {
+	path, _ := c.Flags().GetString("config")
+	discovered := path == ""
+	if discovered {
+		if _, err := os.Stat(config.DefaultFile); err != nil {
+			return nil
+		}
+		path = config.DefaultFile
+	}
+
+	settings, err := config.Load(path)
+	if err != nil {
+		return err
+	}
+	if discovered {
+		if err := settings.CheckRepositorySettings(path); err != nil {
+			return err
+		}
+	}
+	return settings.Apply(c.Flags())
+}
+
+func commandContext(c *cobra.Command) (context.Context, context.CancelFunc) {
+	timeout, _ := c.Flags().GetDuration("timeout")
+	if timeout <= 0 {
+		return context.WithCancel(c.Context())
+	}
+	return context.WithTimeout(c.Context(), timeout)
+}
+
+func timeoutError(c *cobra.Command, err error) error {
+	if errors.Is(err, context.DeadlineExceeded) {
+		timeout, _ := c.Flags().GetDuration("timeout")
+		return fmt.Errorf("timed out after %s: %w", timeout, err)
+	}
+	return err
+}
+
+func newGenerateDeps(ctx context.Context, c *cobra.Command) (cmd.GenerateDeps, error) {
+	config, err := readLLMConfig(c)
+	if err != nil {
+		return cmd.GenerateDeps{}, err
+	}
+
+	runner := &git.DefaultRunner{}
+	tagReader := git.NewTagReader(runner)
+	llmClient, err := provider.NewClientWithConfig(config)
+	if err != nil {
+		return cmd.GenerateDeps{}, err
+	}
+
+	if err := cmd.CheckLLMHealth(ctx, llmClient, config.Provider); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v (using raw commit messages)\n", err)
+	}
+
+	return cmd.GenerateDeps{
+		CommitReader: git.NewCommitReader(runner),
+		LLMClient:    llmClient,
+		TagResolver:  tagReader,
+		TagLister:    tagReader,
+		Cache:        newResponseCache(c),
+	}, nil
+}
+
+func newResponseCache(c *cobra.Command) cmd.ResponseCache {
+	noCache, _ := c.Flags().GetBool("no-cache")
+	if noCache {
+		return nil
+	}
+
+	dir, err := cache.DefaultDir()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v (caching disabled)\n", err)
+		return nil
+	}
+	return cache.New(dir)
+}
+
+func readLLMConfig(c *cobra.Command) (provider.Config, error) {
+	name, _ := c.Flags().GetString("provider")
+	endpoint, _ := c.Flags().GetString("endpoint")
+	ollamaURL, _ := c.Flags().GetString("ollama-url")
+	token, _ := c.Flags().GetString("ollama-token")
+	basicAuth, _ := c.Flags().GetString("ollama-basic-auth")
+	caFile, _ := c.Flags().GetString("ollama-ca-cert")
+	contextLength, _ := c.Flags().GetInt("context-length")
+	llmTimeout, _ := c.Flags().GetDuration("llm-timeout")
+	retries, _ := c.Flags().GetInt("retries")
+	retryDelay, _ := c.Flags().GetDuration("retry-delay")
+	keepAlive, _ := c.Flags().GetString("keep-alive")
+	api, _ := c.Flags().GetString("ollama-api")
+
+	if err := cmd.CheckChoice("ollama-api", api, ollama.APIChat, ollama.APIGenerate); err != nil {
+		return provider.Config{}, err
+	}
+
+	if ollamaURL != "" {
+		if name != provider.Ollama {
+			return provider.Config{}, fmt.Errorf("--ollama-url requires --provider %s", provider.Ollama)
+		}
+		if endpoint != "" {
+			return provider.Config{}, fmt.Errorf("use either --ollama-url or --endpoint, not both")
+		}
+		endpoint = ollamaURL
+	}
+
+	options := ollama.ClientOptions{
+		API:         api,
+		BearerToken: token,
+		CAFile:      caFile,
+		KeepAlive:   keepAlive,
+	}
+	if basicAuth != "" {
+		username, password, found := strings.Cut(basicAuth, ":")
+		if !found {
+			return provider.Config{}, fmt.Errorf("--ollama-basic-auth must be in user:password form")
+		}
+		options.Username, options.Password = username, password
+	}
+
+	return provider.Config{
+		Provider:      name,
+		Endpoint:      endpoint,
+		ContextLength: contextLength,
+		Timeout:       llmTimeout,
+		ModelOptions:  readModelOptions(c),
+		Retry:         llm.RetryPolicy{MaxRetries: retries, BaseDelay: retryDelay},
+		Ollama:        options,
+	}, nil
+}
+
+func readModelOptions(c *cobra.Command) *llm.ModelOptions {
+	name, _ := c.Flags().GetString("provider")
+	contextLength, _ := c.Flags().GetInt("context-length")
+	temperature, _ := c.Flags().GetFloat64("temperature")
+	seed, _ := c.Flags().GetInt("seed")
+	topP, _ := c.Flags().GetFloat64("top-p")
+	numCtx, _ := c.Flags().GetInt("num-ctx")
+	numPredict, _ := c.Flags().GetInt("num-predict")
+
+	// Ollama otherwise loads the model with its own default window, which may be smaller
+	// than the budget the prompts are chunked for.
+	if numCtx == 0 && name == provider.Ollama {
+		numCtx = contextLength
+	}
+
+	return &llm.ModelOptions{
+		Temperature: temperature,
+		Seed:        seed,
+		TopP:        topP,
+		NumCtx:      numCtx,
+		NumPredict:  numPredict,
+	}
+}
+
+func readGenerateOptions(c *cobra.Command) (cmd.GenerateOptions, error) {
+	since, _ := c.Flags().GetString("since")
+	until, _ := c.Flags().GetString("until")
+	format, _ := c.Flags().GetString("format")
+	model, _ := c.Flags().GetString("model")
+	version, _ := c.Flags().GetString("version")
+	groupBy, _ := c.Flags().GetString("group-by")
+	tagPattern, _ := c.Flags().GetString("tag-pattern")
+	skipPrerelease, _ := c.Flags().GetBool("skip-prerelease")
+	prepend, _ := c.Flags().GetBool("prepend")
+	force, _ := c.Flags().GetBool("force")
+	repositoryURL, _ := c.Flags().GetString("repo-url")
+	warmUp, _ := c.Flags().GetBool("warm-up")
+	pull, _ := c.Flags().GetBool("pull")
+	promptTemplatePath, _ := c.Flags().GetString("prompt-template")
+	llmOutput, _ := c.Flags().GetString("llm-output")
+	sections, _ := c.Flags().GetStringSlice("sections")
+	uncited, _ := c.Flags().GetString("uncited")
+	strict, _ := c.Flags().GetBool("strict")
+	minCoverage, _ := c.Flags().GetFloat64("min-coverage")
+	verbose, _ := c.Flags().GetBool("verbose")
+	examplesFrom, _ := c.Flags().GetString("examples-from")
+	maxExamples, _ := c.Flags().GetInt("examples")
+	refinements, _ := c.Flags().GetStringArray("refine")
+
+	var promptTemplate *llm.PromptTemplate
+	if promptTemplatePath != "" {
+		var err error
+		if promptTemplate, err = llm.LoadPromptTemplate(promptTemplatePath); err != nil {
+			return cmd.GenerateOptions{}, err
+		}
+	}
+
+	redactor, err := newRedactor(c)
+	if err != nil {
+		return cmd.GenerateOptions{}, err
+	}
+
+	config, err := readLLMConfig(c)
+	if err != nil {
+		return cmd.GenerateOptions{}, err
+	}
+	endpoint, err := provider.ResolveEndpoint(config)
+	if err != nil {
+		return cmd.GenerateOptions{}, err
+	}
+	ollamaAPI := ""
+	if config.Provider != provider.OpenAI {
+		ollamaAPI = config.Ollama.API
+	}
+
+	if format == cmd.FormatKeepAChangelog && repositoryURL == "" {
+		repositoryURL, _ = git.RepositoryURL(c.Context(), &git.DefaultRunner{}, "origin")
+	}
+
+	opts := cmd.GenerateOptions{
+		Format:         format,
+		Since:          since,
+		Until:          until,
+		Model:          model,
+		Version:        version,
+		GroupBy:        groupBy,
+		TagPattern:     tagPattern,
+		SkipPrerelease: skipPrerelease,
+		Prepend:        prepend,
+		Force:          force,
+		RepositoryURL:  repositoryURL,
+		WarmUp:         warmUp,
+		Pull:           pull,
+		ModelOptions:   readModelOptions(c),
+		PromptTemplate: promptTemplate,
+		LLMOutput:      llmOutput,
+		Sections:       sections,
+		Uncited:        uncited,
+		Strict:         strict,
+		MinCoverage:    minCoverage,
+		Redactor:       redactor,
+		Verbose:        verbose,
+		ExamplesFrom:   examplesFrom,
+		MaxExamples:    maxExamples,
+		Refinements:    refinements,
+		Provider:       config.Provider,
+		Endpoint:       endpoint,
+		OllamaAPI:      ollamaAPI,
+	}
+	if err := opts.Validate(); err != nil {
+		return cmd.GenerateOptions{}, err
+	}
+	return opts, nil
+}
+
+func newRedactor(c *cobra.Command) (*redact.Redactor, error) {
+	noRedact, _ := c.Flags().GetBool("no-redact")
+	if noRedact {
+		return nil, nil
+	}
+
+	patterns, _ := c.Flags().GetStringArray("redact")
+	return redact.NewWithPatterns(patterns)
+}
+
+func isTerminal(file *os.File) bool {
+	info, err := file.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
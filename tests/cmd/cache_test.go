@@ -0,0 +1,32 @@
+package cmd_test
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/brognilucas/ai-changelog/cmd"
+	"github.com/brognilucas/ai-changelog/internal/cache"
+)
+
+func TestRunCacheClear(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "ai-changelog")
+	responses := cache.New(dir)
+	if err := responses.Put(cache.Key("a"), "value"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var output bytes.Buffer
+	if err := cmd.RunCacheClear(responses, &output); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, ok := responses.Get(cache.Key("a")); ok {
+		t.Error("expected cached responses to be removed")
+	}
+
+	if !strings.Contains(output.String(), dir) {
+		t.Errorf("expected output to name the cleared directory, got %q", output.String())
+	}
+}
@@ -106,3 +106,305 @@ func TestVersionFlag(t *testing.T) {
 		t.Errorf("expected --version shorthand to be 'V', got %q", flag.Shorthand)
 	}
 }
+
+func TestGroupByFlag(t *testing.T) {
+	rootCmd := cmd.NewRootCommand()
+
+	flag := rootCmd.PersistentFlags().Lookup("group-by")
+	if flag == nil {
+		t.Fatal("expected --group-by flag to exist")
+	}
+
+	if flag.DefValue != "category" {
+		t.Errorf("expected --group-by default to be 'category', got %q", flag.DefValue)
+	}
+}
+
+func TestUntilFlag(t *testing.T) {
+	rootCmd := cmd.NewRootCommand()
+
+	flag := rootCmd.PersistentFlags().Lookup("until")
+	if flag == nil {
+		t.Fatal("expected --until flag to exist")
+	}
+
+	if flag.DefValue != "" {
+		t.Errorf("expected --until default to be empty, got %q", flag.DefValue)
+	}
+
+	if flag.Shorthand != "u" {
+		t.Errorf("expected --until shorthand to be 'u', got %q", flag.Shorthand)
+	}
+}
+
+func TestPrependFlags(t *testing.T) {
+	rootCmd := cmd.NewRootCommand()
+
+	for _, name := range []string{"prepend", "force"} {
+		flag := rootCmd.PersistentFlags().Lookup(name)
+		if flag == nil {
+			t.Fatalf("expected --%s flag to exist", name)
+		}
+
+		if flag.DefValue != "false" {
+			t.Errorf("expected --%s default to be false, got %q", name, flag.DefValue)
+		}
+	}
+}
+
+func TestProviderFlags(t *testing.T) {
+	rootCmd := cmd.NewRootCommand()
+
+	provider := rootCmd.PersistentFlags().Lookup("provider")
+	if provider == nil {
+		t.Fatal("expected --provider flag to exist")
+	}
+
+	if provider.DefValue != "ollama" {
+		t.Errorf("expected --provider default to be 'ollama', got %q", provider.DefValue)
+	}
+
+	endpoint := rootCmd.PersistentFlags().Lookup("endpoint")
+	if endpoint == nil {
+		t.Fatal("expected --endpoint flag to exist")
+	}
+
+	if endpoint.DefValue != "" {
+		t.Errorf("expected --endpoint default to be empty, got %q", endpoint.DefValue)
+	}
+}
+
+func TestOllamaConnectionFlags(t *testing.T) {
+	rootCmd := cmd.NewRootCommand()
+
+	for _, name := range []string{"ollama-url", "ollama-token", "ollama-basic-auth", "ollama-ca-cert"} {
+		flag := rootCmd.PersistentFlags().Lookup(name)
+		if flag == nil {
+			t.Fatalf("expected --%s flag to exist", name)
+		}
+
+		if flag.DefValue != "" {
+			t.Errorf("expected --%s default to be empty, got %q", name, flag.DefValue)
+		}
+	}
+}
+
+func TestContextLengthFlag(t *testing.T) {
+	rootCmd := cmd.NewRootCommand()
+
+	flag := rootCmd.PersistentFlags().Lookup("context-length")
+	if flag == nil {
+		t.Fatal("expected --context-length flag to exist")
+	}
+
+	if flag.DefValue != "4096" {
+		t.Errorf("expected --context-length default to be 4096, got %q", flag.DefValue)
+	}
+}
+
+func TestTimeoutFlags(t *testing.T) {
+	rootCmd := cmd.NewRootCommand()
+
+	timeout := rootCmd.PersistentFlags().Lookup("timeout")
+	if timeout == nil {
+		t.Fatal("expected --timeout flag to exist")
+	}
+
+	if timeout.DefValue != "0s" {
+		t.Errorf("expected --timeout default to be 0s, got %q", timeout.DefValue)
+	}
+
+	llmTimeout := rootCmd.PersistentFlags().Lookup("llm-timeout")
+	if llmTimeout == nil {
+		t.Fatal("expected --llm-timeout flag to exist")
+	}
+
+	if llmTimeout.DefValue != "2m0s" {
+		t.Errorf("expected --llm-timeout default to be 2m0s, got %q", llmTimeout.DefValue)
+	}
+}
+
+func TestRetryFlags(t *testing.T) {
+	rootCmd := cmd.NewRootCommand()
+
+	expected := map[string]string{
+		"retries":     "3",
+		"retry-delay": "1s",
+		"warm-up":     "false",
+		"keep-alive":  "",
+	}
+
+	for name, defValue := range expected {
+		flag := rootCmd.PersistentFlags().Lookup(name)
+		if flag == nil {
+			t.Fatalf("expected --%s flag to exist", name)
+		}
+
+		if flag.DefValue != defValue {
+			t.Errorf("expected --%s default to be %q, got %q", name, defValue, flag.DefValue)
+		}
+	}
+}
+
+func TestPullFlag(t *testing.T) {
+	rootCmd := cmd.NewRootCommand()
+
+	flag := rootCmd.PersistentFlags().Lookup("pull")
+	if flag == nil {
+		t.Fatal("expected --pull flag to exist")
+	}
+
+	if flag.DefValue != "false" {
+		t.Errorf("expected --pull default to be false, got %q", flag.DefValue)
+	}
+}
+
+func TestModelsCommand(t *testing.T) {
+	modelsCmd := cmd.NewModelsCommand()
+
+	if modelsCmd.Use != "models" {
+		t.Errorf("expected command use to be models, got %q", modelsCmd.Use)
+	}
+}
+
+func TestModelOptionFlags(t *testing.T) {
+	rootCmd := cmd.NewRootCommand()
+
+	expected := map[string]string{
+		"temperature": "0.2",
+		"seed":        "42",
+		"top-p":       "0.9",
+		"num-ctx":     "0",
+		"num-predict": "0",
+		"config":      "",
+	}
+
+	for name, defValue := range expected {
+		flag := rootCmd.PersistentFlags().Lookup(name)
+		if flag == nil {
+			t.Fatalf("expected --%s flag to exist", name)
+		}
+
+		if flag.DefValue != defValue {
+			t.Errorf("expected --%s default to be %q, got %q", name, defValue, flag.DefValue)
+		}
+	}
+}
+
+func TestCacheCommands(t *testing.T) {
+	rootCmd := cmd.NewRootCommand()
+
+	flag := rootCmd.PersistentFlags().Lookup("no-cache")
+	if flag == nil {
+		t.Fatal("expected --no-cache flag to exist")
+	}
+
+	if flag.DefValue != "false" {
+		t.Errorf("expected --no-cache default to be false, got %q", flag.DefValue)
+	}
+
+	if cmd.NewCacheCommand().Use != "cache" || cmd.NewCacheClearCommand().Use != "clear" {
+		t.Error("expected cache and clear commands")
+	}
+}
+
+func TestPromptTemplateFlag(t *testing.T) {
+	rootCmd := cmd.NewRootCommand()
+
+	flag := rootCmd.PersistentFlags().Lookup("prompt-template")
+	if flag == nil {
+		t.Fatal("expected --prompt-template flag to exist")
+	}
+
+	if flag.DefValue != "" {
+		t.Errorf("expected --prompt-template default to be empty, got %q", flag.DefValue)
+	}
+}
+
+func TestLLMOutputFlags(t *testing.T) {
+	rootCmd := cmd.NewRootCommand()
+
+	flag := rootCmd.PersistentFlags().Lookup("llm-output")
+	if flag == nil {
+		t.Fatal("expected --llm-output flag to exist")
+	}
+
+	if flag.DefValue != cmd.LLMOutputMarkdown {
+		t.Errorf("expected --llm-output default to be markdown, got %q", flag.DefValue)
+	}
+
+	if rootCmd.PersistentFlags().Lookup("sections") == nil {
+		t.Fatal("expected --sections flag to exist")
+	}
+}
+
+func TestVerificationFlags(t *testing.T) {
+	rootCmd := cmd.NewRootCommand()
+
+	tests := []struct {
+		name     string
+		defValue string
+	}{
+		{"uncited", cmd.UncitedDrop},
+		{"strict", "false"},
+		{"min-coverage", "0.8"},
+	}
+
+	for _, tt := range tests {
+		flag := rootCmd.PersistentFlags().Lookup(tt.name)
+		if flag == nil {
+			t.Fatalf("expected --%s flag to exist", tt.name)
+		}
+		if flag.DefValue != tt.defValue {
+			t.Errorf("expected --%s default to be %q, got %q", tt.name, tt.defValue, flag.DefValue)
+		}
+	}
+}
+
+func TestRedactionFlags(t *testing.T) {
+	rootCmd := cmd.NewRootCommand()
+
+	for _, name := range []string{"redact", "no-redact", "verbose"} {
+		if rootCmd.PersistentFlags().Lookup(name) == nil {
+			t.Errorf("expected --%s flag to exist", name)
+		}
+	}
+
+	if flag := rootCmd.PersistentFlags().Lookup("no-redact"); flag != nil && flag.DefValue != "false" {
+		t.Errorf("expected redaction to be on by default, got --no-redact=%s", flag.DefValue)
+	}
+}
+
+func TestOllamaAPIFlag(t *testing.T) {
+	rootCmd := cmd.NewRootCommand()
+
+	flag := rootCmd.PersistentFlags().Lookup("ollama-api")
+	if flag == nil {
+		t.Fatal("expected --ollama-api flag to exist")
+	}
+
+	if flag.DefValue != "chat" {
+		t.Errorf("expected --ollama-api default to be chat, got %q", flag.DefValue)
+	}
+}
+
+func TestChatFlags(t *testing.T) {
+	rootCmd := cmd.NewRootCommand()
+
+	expected := map[string]string{
+		"examples-from": "",
+		"examples":      "2",
+		"refine":        "[]",
+	}
+
+	for name, defValue := range expected {
+		flag := rootCmd.PersistentFlags().Lookup(name)
+		if flag == nil {
+			t.Fatalf("expected --%s flag to exist", name)
+		}
+
+		if flag.DefValue != defValue {
+			t.Errorf("expected --%s default to be %q, got %q", name, defValue, flag.DefValue)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package cmd_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/brognilucas/ai-changelog/cmd"
+	"github.com/brognilucas/ai-changelog/internal/git"
+)
+
+const examplesChangelog = `# Changelog
+
+## [Unreleased]
+
+- Work in progress
+
+## [1.2.0] - 2024-03-01
+
+### Highlights
+
+- Export reports as CSV (ccc3333)
+
+## [1.1.0] - 2024-02-01
+
+### Bug Fixes
+
+- Fix a crash on startup (bbb2222)
+
+## [0.9.0] - 2023-12-01
+
+- Released before tags were used
+
+[1.2.0]: https://example.com/compare/v1.1.0...v1.2.0
+`
+
+func TestGenerateLoadsExamplesFromChangelog(t *testing.T) {
+	deps, commitReader := newHistoryDeps()
+	commitReader.commits[git.CommitRange{Since: "v1.2.0"}] = []git.Commit{{Hash: "ddd4444", Subject: "feat: dark mode", Prefix: "feat"}}
+	client := &mockOllamaClient{healthy: true, changelogOutput: "## Highlights\n\n- Dark mode (ddd4444)\n"}
+	deps.LLMClient = client
+
+	path := filepath.Join(t.TempDir(), "CHANGELOG.md")
+	if err := os.WriteFile(path, []byte(examplesChangelog), 0644); err != nil {
+		t.Fatalf("failed to write changelog: %v", err)
+	}
+
+	var output bytes.Buffer
+	opts := cmd.GenerateOptions{Format: "markdown", Since: "v1.2.0", ExamplesFrom: path, MaxExamples: 1, Refinements: []string{"make it shorter"}}
+	if err := cmd.RunGenerate(context.Background(), deps, opts, &output); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	examples := client.lastRequest.Examples
+	if len(examples) != 1 {
+		t.Fatalf("expected one example, got %+v", examples)
+	}
+	if examples[0].Version != "v1.2.0" || examples[0].Changelog != "### Highlights\n\n- Export reports as CSV (ccc3333)" {
+		t.Errorf("expected the latest release as the example, got %+v", examples[0])
+	}
+	if len(examples[0].Commits) != 1 || examples[0].Commits[0].Hash != "ccc3333" {
+		t.Errorf("expected the commits between v1.1.0 and v1.2.0, got %+v", examples[0].Commits)
+	}
+
+	if strings.Join(client.lastRequest.Refinements, ",") != "make it shorter" {
+		t.Errorf("expected the refinements to be passed on, got %q", client.lastRequest.Refinements)
+	}
+}
+
+func TestGenerateSkipsExampleForTheReleaseBeingWritten(t *testing.T) {
+	deps, _ := newHistoryDeps()
+	client := &mockOllamaClient{healthy: true, changelogOutput: "## Highlights\n\n- Export reports (ccc3333)\n"}
+	deps.LLMClient = client
+
+	path := filepath.Join(t.TempDir(), "CHANGELOG.md")
+	os.WriteFile(path, []byte(examplesChangelog), 0644)
+
+	var output bytes.Buffer
+	opts := cmd.GenerateOptions{Format: "markdown", Since: "v1.1.0", Until: "v1.2.0", Version: "v1.2.0", ExamplesFrom: path, MaxExamples: 2}
+	if err := cmd.RunGenerate(context.Background(), deps, opts, &output); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	examples := client.lastRequest.Examples
+	if len(examples) != 1 || examples[0].Version != "v1.1.0" {
+		t.Errorf("expected only the tagged release before v1.2.0, got %+v", examples)
+	}
+}
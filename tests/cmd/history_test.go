@@ -0,0 +1,174 @@
+package cmd_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/brognilucas/ai-changelog/cmd"
+	"github.com/brognilucas/ai-changelog/internal/git"
+)
+
+type rangeCommitReader struct {
+	commits        map[git.CommitRange][]git.Commit
+	rangesReceived []git.CommitRange
+}
+
+func (m *rangeCommitReader) GetCommits(ctx context.Context, commitRange git.CommitRange) ([]git.Commit, error) {
+	m.rangesReceived = append(m.rangesReceived, commitRange)
+	return m.commits[commitRange], nil
+}
+
+type mockTagLister struct {
+	tags []git.Tag
+	err  error
+}
+
+func (m *mockTagLister) ListTags(ctx context.Context, pattern string, skipPrerelease bool) ([]git.Tag, error) {
+	return m.tags, m.err
+}
+
+func newHistoryDeps() (cmd.GenerateDeps, *rangeCommitReader) {
+	commitReader := &rangeCommitReader{
+		commits: map[git.CommitRange][]git.Commit{
+			{Until: "v1.0.0"}:                  {{Hash: "aaa1111", Subject: "feat: initial release", Prefix: "feat"}},
+			{Since: "v1.0.0", Until: "v1.1.0"}: {{Hash: "bbb2222", Subject: "fix: resolve crash", Prefix: "fix"}},
+			{Since: "v1.1.0", Until: "v1.2.0"}: {{Hash: "ccc3333", Subject: "feat: add export", Prefix: "feat"}},
+		},
+	}
+
+	tagLister := &mockTagLister{
+		tags: []git.Tag{
+			{Name: "v1.0.0", Date: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)},
+			{Name: "v1.1.0", Date: time.Date(2024, 2, 1, 12, 0, 0, 0, time.UTC)},
+			{Name: "v1.2.0", Date: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)},
+		},
+	}
+
+	return cmd.GenerateDeps{
+		CommitReader: commitReader,
+		LLMClient:    &mockOllamaClient{healthy: false},
+		TagLister:    tagLister,
+	}, commitReader
+}
+
+func TestRunHistory(t *testing.T) {
+	deps, commitReader := newHistoryDeps()
+
+	var output bytes.Buffer
+	if err := cmd.RunHistory(context.Background(), deps, cmd.GenerateOptions{Format: "markdown"}, &output); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	result := output.String()
+
+	newest := strings.Index(result, "# Changelog v1.2.0 (2024-03-01)")
+	middle := strings.Index(result, "# Changelog v1.1.0 (2024-02-01)")
+	oldest := strings.Index(result, "# Changelog v1.0.0 (2024-01-01)")
+	if newest == -1 || middle == -1 || oldest == -1 {
+		t.Fatalf("expected a section per release with tag dates, got:\n%s", result)
+	}
+
+	if !(newest < middle && middle < oldest) {
+		t.Errorf("expected releases newest first, got:\n%s", result)
+	}
+
+	if !strings.Contains(result, "- add export (ccc3333)\n\n# Changelog v1.1.0") {
+		t.Errorf("expected releases separated by a blank line, got:\n%s", result)
+	}
+
+	if len(commitReader.rangesReceived) != 3 {
+		t.Errorf("expected one commit lookup per release, got %v", commitReader.rangesReceived)
+	}
+}
+
+func TestRunHistorySkipsEmptyReleases(t *testing.T) {
+	deps, commitReader := newHistoryDeps()
+	delete(commitReader.commits, git.CommitRange{Since: "v1.0.0", Until: "v1.1.0"})
+
+	var output bytes.Buffer
+	if err := cmd.RunHistory(context.Background(), deps, cmd.GenerateOptions{Format: "markdown"}, &output); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if strings.Contains(output.String(), "v1.1.0") {
+		t.Errorf("expected release without commits to be skipped, got:\n%s", output.String())
+	}
+}
+
+func TestRunHistoryNoTags(t *testing.T) {
+	deps, _ := newHistoryDeps()
+	deps.TagLister = &mockTagLister{}
+
+	var output bytes.Buffer
+	if err := cmd.RunHistory(context.Background(), deps, cmd.GenerateOptions{}, &output); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if output.String() != "No tags found.\n" {
+		t.Errorf("expected 'No tags found.', got %q", output.String())
+	}
+}
+
+func TestRunHistoryWithLLM(t *testing.T) {
+	deps, _ := newHistoryDeps()
+	deps.LLMClient = &mockOllamaClient{healthy: true, changelogOutput: "## Highlights\n\n- Something new (aaa1111, bbb2222, ccc3333)"}
+
+	var output bytes.Buffer
+	if err := cmd.RunHistory(context.Background(), deps, cmd.GenerateOptions{}, &output); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !strings.Contains(output.String(), "# v1.2.0 (2024-03-01)\n\n## Highlights\n\n- Something new (aaa1111, bbb2222, ccc3333)\n\n# v1.1.0") {
+		t.Errorf("expected LLM output per release, got:\n%s", output.String())
+	}
+}
+
+func TestWriteHistoryToFile(t *testing.T) {
+	deps, _ := newHistoryDeps()
+
+	outputPath := filepath.Join(t.TempDir(), "CHANGELOG.md")
+	if err := cmd.WriteHistoryToFile(context.Background(), deps, cmd.GenerateOptions{Format: "markdown"}, outputPath); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	content, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatalf("failed to read output file: %v", err)
+	}
+
+	if !strings.Contains(string(content), "v1.0.0 (2024-01-01)") {
+		t.Errorf("expected file to contain the oldest release, got:\n%s", content)
+	}
+}
+
+func TestRunHistoryKeepAChangelog(t *testing.T) {
+	deps, _ := newHistoryDeps()
+
+	var output bytes.Buffer
+	opts := cmd.GenerateOptions{Format: "keepachangelog", RepositoryURL: "https://github.com/acme/app"}
+	if err := cmd.RunHistory(context.Background(), deps, opts, &output); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	result := output.String()
+
+	if strings.Count(result, "# Changelog\n") != 1 {
+		t.Errorf("expected a single title, got:\n%s", result)
+	}
+
+	expectedLinks := "[1.2.0]: https://github.com/acme/app/compare/v1.1.0...v1.2.0\n[1.1.0]: https://github.com/acme/app/compare/v1.0.0...v1.1.0\n[1.0.0]: https://github.com/acme/app/releases/tag/v1.0.0\n"
+	if !strings.HasSuffix(result, expectedLinks) {
+		t.Errorf("expected compare links newest first at the bottom, got:\n%s", result)
+	}
+
+	newest := strings.Index(result, "## [1.2.0] - 2024-03-01")
+	oldest := strings.Index(result, "## [1.0.0] - 2024-01-01")
+	if newest == -1 || oldest == -1 || newest > oldest {
+		t.Errorf("expected dated releases newest first, got:\n%s", result)
+	}
+}
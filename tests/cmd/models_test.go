@@ -0,0 +1,72 @@
+package cmd_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/brognilucas/ai-changelog/cmd"
+	"github.com/brognilucas/ai-changelog/internal/ollama"
+)
+
+type mockModelLister struct {
+	models         []ollama.Model
+	contextLengths map[string]int
+}
+
+func (m *mockModelLister) ListModels(ctx context.Context) ([]ollama.Model, error) {
+	return m.models, nil
+}
+
+func (m *mockModelLister) ContextLength(ctx context.Context, model string) (int, error) {
+	length, ok := m.contextLengths[model]
+	if !ok {
+		return 0, errors.New("model info unavailable")
+	}
+	return length, nil
+}
+
+func TestRunModels(t *testing.T) {
+	lister := &mockModelLister{
+		models: []ollama.Model{
+			{Name: "llama3.2:latest", Size: 2019393189, Details: ollama.ModelDetails{ParameterSize: "3.2B"}},
+			{Name: "nomic-embed-text:latest", Size: 274302450},
+		},
+		contextLengths: map[string]int{"llama3.2:latest": 131072},
+	}
+
+	var output bytes.Buffer
+	if err := cmd.RunModels(context.Background(), lister, &output); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected header and 2 rows, got:\n%s", output.String())
+	}
+
+	if strings.Join(strings.Fields(lines[0]), " ") != "NAME PARAMETERS SIZE CONTEXT" {
+		t.Errorf("unexpected header: %q", lines[0])
+	}
+
+	if strings.Join(strings.Fields(lines[1]), " ") != "llama3.2:latest 3.2B 2.0 GB 131072" {
+		t.Errorf("unexpected row: %q", lines[1])
+	}
+
+	if strings.Join(strings.Fields(lines[2]), " ") != "nomic-embed-text:latest - 274.3 MB -" {
+		t.Errorf("unexpected row: %q", lines[2])
+	}
+}
+
+func TestRunModelsEmpty(t *testing.T) {
+	var output bytes.Buffer
+	if err := cmd.RunModels(context.Background(), &mockModelLister{}, &output); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !strings.Contains(output.String(), "No models found") {
+		t.Errorf("expected 'No models found', got %q", output.String())
+	}
+}
@@ -0,0 +1,76 @@
+package cache_test
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/brognilucas/ai-changelog/internal/cache"
+)
+
+func TestPutAndGet(t *testing.T) {
+	c := cache.New(filepath.Join(t.TempDir(), "ai-changelog"))
+	key := cache.Key("changelog", "llama3.2", "abc1234")
+
+	if _, ok := c.Get(key); ok {
+		t.Fatal("expected a miss before Put")
+	}
+
+	if err := c.Put(key, "## Highlights\n\n- Login\n"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	value, ok := c.Get(key)
+	if !ok || value != "## Highlights\n\n- Login\n" {
+		t.Errorf("expected the stored value, got %q (hit=%v)", value, ok)
+	}
+}
+
+func TestKey(t *testing.T) {
+	if cache.Key("ab", "c") == cache.Key("a", "bc") {
+		t.Error("expected part boundaries to change the key")
+	}
+
+	if cache.Key("llama3.2", "abc") != cache.Key("llama3.2", "abc") {
+		t.Error("expected keys to be stable")
+	}
+}
+
+func TestClear(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "ai-changelog")
+	c := cache.New(dir)
+
+	if err := c.Put(cache.Key("a"), "value"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := c.Clear(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("expected cache directory to be removed, got %v", err)
+	}
+
+	if err := c.Clear(); err != nil {
+		t.Errorf("expected clearing an empty cache to succeed, got %v", err)
+	}
+}
+
+func TestDefaultDirUsesXDGCacheHome(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("XDG_CACHE_HOME only applies on Linux")
+	}
+
+	t.Setenv("XDG_CACHE_HOME", "/tmp/xdg-cache")
+
+	dir, err := cache.DefaultDir()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if dir != "/tmp/xdg-cache/ai-changelog" {
+		t.Errorf("expected /tmp/xdg-cache/ai-changelog, got %s", dir)
+	}
+}
@@ -0,0 +1,71 @@
+package changelog_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/brognilucas/ai-changelog/internal/changelog"
+)
+
+func TestGroupEntriesFollowsSectionOrder(t *testing.T) {
+	entries := []changelog.Entry{
+		{Section: "Fixed", Text: "Fix crash", Commits: []string{"bbb2222"}},
+		{Section: "Added", Text: "Add login", Commits: []string{"aaa1111"}},
+		{Section: "Added", Text: "Add logout", Commits: []string{"ccc3333"}},
+	}
+
+	sections := changelog.GroupEntries(entries, []string{"Added", "Changed", "Fixed"})
+
+	if len(sections) != 2 {
+		t.Fatalf("expected 2 sections, got %d", len(sections))
+	}
+	if sections[0].Title != "Added" || len(sections[0].Entries) != 2 {
+		t.Errorf("expected Added with 2 entries first, got %+v", sections[0])
+	}
+	if sections[1].Title != "Fixed" {
+		t.Errorf("expected Fixed second, got %s", sections[1].Title)
+	}
+}
+
+func TestRenderersRenderEntries(t *testing.T) {
+	sections := changelog.GroupEntries([]changelog.Entry{
+		{Section: "Added", Text: "Add login", Commits: []string{"aaa1111", "ccc3333"}},
+	}, changelog.KeepAChangelogSections())
+
+	tests := []struct {
+		name     string
+		renderer changelog.Renderer
+		expected []string
+	}{
+		{"markdown", &changelog.MarkdownRenderer{Summary: "A small release."}, []string{"_A small release._", "## Added", "- Add login (aaa1111, ccc3333)"}},
+		{"plain", &changelog.PlainTextRenderer{Summary: "A small release."}, []string{"A small release.", "ADDED", "  * Add login (aaa1111, ccc3333)"}},
+		{"keepachangelog", &changelog.KeepAChangelogRenderer{Summary: "A small release."}, []string{"A small release.", "### Added", "- Add login (aaa1111, ccc3333)"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := tt.renderer.Render(sections, "v1.0.0")
+			for _, expected := range tt.expected {
+				if !strings.Contains(result, expected) {
+					t.Errorf("expected output to contain %q, got:\n%s", expected, result)
+				}
+			}
+		})
+	}
+}
+
+func TestJSONRendererIncludesLLMEntries(t *testing.T) {
+	renderer := &changelog.JSONRenderer{
+		Mode:       changelog.ModeLLM,
+		LLMSummary: "A small release.",
+		LLMEntries: []changelog.Entry{{Section: "Highlights", Text: "Add login", Commits: []string{"aaa1111"}}},
+	}
+
+	result := renderer.Render(nil, "v1.0.0")
+
+	for _, expected := range []string{`"summary": "A small release."`, `"section": "Highlights"`, `"text": "Add login"`} {
+		if !strings.Contains(result, expected) {
+			t.Errorf("expected output to contain %q, got:\n%s", expected, result)
+		}
+	}
+}
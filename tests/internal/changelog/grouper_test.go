@@ -21,6 +21,7 @@ func TestCategoryDisplayNames(t *testing.T) {
 		{"chore", "Maintenance"},
 		{"test", "Testing"},
 		{"style", "Style"},
+		{"breaking", "Breaking Changes"},
 		{"other", "Other"},
 	}
 
@@ -213,4 +214,85 @@ func TestGroupUnknownPrefixAppearsLast(t *testing.T) {
 	if lastSection.Title != "Other" {
 		t.Errorf("expected 'Other' section to appear last, but got %q", lastSection.Title)
 	}
-}
\ No newline at end of file
+}
+
+func TestGroupBreakingChangesFirst(t *testing.T) {
+	baseTime := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+
+	commits := []git.Commit{
+		{Hash: "abc123", Subject: "feat: add login", Author: "Alice", Timestamp: baseTime, Prefix: "feat"},
+		{Hash: "def456", Subject: "fix!: drop legacy tokens", Author: "Bob", Timestamp: baseTime.Add(time.Hour), Prefix: "fix", Breaking: true, BreakingDescription: "drop legacy tokens"},
+	}
+
+	sections := changelog.GroupByCategory(commits)
+
+	if len(sections) != 2 {
+		t.Fatalf("expected 2 sections, got %d", len(sections))
+	}
+
+	if sections[0].Title != "Breaking Changes" {
+		t.Errorf("expected first section to be 'Breaking Changes', got %q", sections[0].Title)
+	}
+
+	if len(sections[0].Commits) != 1 || sections[0].Commits[0].Hash != "def456" {
+		t.Errorf("expected breaking commit in 'Breaking Changes', got %v", sections[0].Commits)
+	}
+
+	if sections[1].Title != "New Features" {
+		t.Errorf("expected breaking fix not to create a 'Bug Fixes' section, got %q", sections[1].Title)
+	}
+}
+
+func TestGroupByCategoryAndScope(t *testing.T) {
+	baseTime := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+
+	commits := []git.Commit{
+		{Hash: "abc123", Subject: "feat(web): add dark mode", Timestamp: baseTime, Prefix: "feat", Scope: "web"},
+		{Hash: "def456", Subject: "feat(api): add pagination", Timestamp: baseTime, Prefix: "feat", Scope: "api"},
+		{Hash: "ghi789", Subject: "feat: add login", Timestamp: baseTime, Prefix: "feat"},
+		{Hash: "jkl012", Subject: "feat(api): add filtering", Timestamp: baseTime, Prefix: "feat", Scope: "api"},
+		{Hash: "mno345", Subject: "fix(web): fix crash", Timestamp: baseTime, Prefix: "fix", Scope: "web"},
+	}
+
+	sections := changelog.GroupByCategoryAndScope(commits)
+
+	if len(sections) != 2 {
+		t.Fatalf("expected 2 sections, got %d", len(sections))
+	}
+
+	scopes := sections[0].Scopes
+	expectedScopes := []string{"", "api", "web"}
+	if len(scopes) != len(expectedScopes) {
+		t.Fatalf("expected %d scope groups, got %d", len(expectedScopes), len(scopes))
+	}
+
+	for i, expected := range expectedScopes {
+		if scopes[i].Scope != expected {
+			t.Errorf("scopes[%d].Scope = %q, want %q", i, scopes[i].Scope, expected)
+		}
+	}
+
+	if len(scopes[1].Commits) != 2 {
+		t.Errorf("expected 2 commits in 'api' scope, got %d", len(scopes[1].Commits))
+	}
+
+	if len(sections[0].Commits) != 4 {
+		t.Errorf("expected section to keep all 4 commits, got %d", len(sections[0].Commits))
+	}
+
+	if len(sections[1].Scopes) != 1 || sections[1].Scopes[0].Scope != "web" {
+		t.Errorf("expected single 'web' scope in 'Bug Fixes', got %v", sections[1].Scopes)
+	}
+}
+
+func TestGroupByCategoryLeavesScopesEmpty(t *testing.T) {
+	commits := []git.Commit{
+		{Hash: "abc123", Subject: "feat(api): add pagination", Prefix: "feat", Scope: "api"},
+	}
+
+	sections := changelog.GroupByCategory(commits)
+
+	if len(sections) != 1 || sections[0].Scopes != nil {
+		t.Errorf("expected GroupByCategory not to nest scopes, got %+v", sections)
+	}
+}
@@ -0,0 +1,133 @@
+package changelog_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/brognilucas/ai-changelog/internal/changelog"
+	"github.com/brognilucas/ai-changelog/internal/git"
+)
+
+func TestJSONRendererFallback(t *testing.T) {
+	var _ changelog.Renderer = &changelog.JSONRenderer{}
+
+	baseTime := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	sections := []changelog.ChangelogSection{
+		{
+			Title: "New Features",
+			Commits: []git.Commit{
+				{Hash: "abc1234def", Subject: "feat(api)!: add export", Author: "Alice", Timestamp: baseTime, Prefix: "feat", Scope: "api", Breaking: true},
+			},
+		},
+		{Title: "Bug Fixes"},
+	}
+
+	renderer := &changelog.JSONRenderer{
+		Date:  time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+		Since: "v1.1.0",
+		Until: "v1.2.0",
+		Model: "llama3.2",
+	}
+	result := renderer.Render(sections, "v1.2.0")
+
+	var document changelog.JSONDocument
+	if err := json.Unmarshal([]byte(result), &document); err != nil {
+		t.Fatalf("expected valid JSON, got %v:\n%s", err, result)
+	}
+
+	if document.SchemaVersion != changelog.JSONSchemaVersion {
+		t.Errorf("expected schema version %d, got %d", changelog.JSONSchemaVersion, document.SchemaVersion)
+	}
+
+	if document.Version != "v1.2.0" || document.Date != "2024-05-01" || document.Mode != "fallback" || document.Model != "llama3.2" {
+		t.Errorf("unexpected metadata: %+v", document)
+	}
+
+	if document.Range.Since != "v1.1.0" || document.Range.Until != "v1.2.0" {
+		t.Errorf("unexpected range: %+v", document.Range)
+	}
+
+	if document.LLM != nil {
+		t.Errorf("expected no llm block in fallback mode, got %+v", document.LLM)
+	}
+
+	if len(document.Sections) != 1 {
+		t.Fatalf("expected empty sections to be skipped, got %d sections", len(document.Sections))
+	}
+
+	expected := changelog.JSONEntry{
+		Subject:        "feat(api)!: add export",
+		CleanedSubject: "add export",
+		Hash:           "abc1234def",
+		Author:         "Alice",
+		Timestamp:      "2024-01-15T10:00:00Z",
+		Category:       "feat",
+		Scope:          "api",
+		Breaking:       true,
+	}
+	if document.Sections[0].Entries[0] != expected {
+		t.Errorf("unexpected entry:\n%+v\nwant:\n%+v", document.Sections[0].Entries[0], expected)
+	}
+}
+
+func TestJSONRendererLLM(t *testing.T) {
+	renderer := &changelog.JSONRenderer{
+		Mode:       changelog.ModeLLM,
+		LLMText:    "## Highlights\n\n- Export\n",
+		LLMCommits: []string{"abc1234def", "def4567ghi"},
+	}
+
+	var document changelog.JSONDocument
+	if err := json.Unmarshal([]byte(renderer.Render(nil, "")), &document); err != nil {
+		t.Fatalf("expected valid JSON, got %v", err)
+	}
+
+	if document.Mode != "llm" || document.LLM == nil {
+		t.Fatalf("expected llm block, got %+v", document)
+	}
+
+	if document.LLM.Text != "## Highlights\n\n- Export\n" || len(document.LLM.Commits) != 2 {
+		t.Errorf("unexpected llm block: %+v", document.LLM)
+	}
+
+	if document.Sections == nil {
+		t.Error("expected sections to be an empty array, not null")
+	}
+
+	if strings.Contains(renderer.Render(nil, ""), `"options"`) {
+		t.Error("expected options to be omitted when not recorded")
+	}
+}
+
+func TestJSONRendererLLMOptions(t *testing.T) {
+	renderer := &changelog.JSONRenderer{
+		Mode:       changelog.ModeLLM,
+		LLMText:    "## Highlights\n\n- Export\n",
+		LLMOptions: &changelog.JSONModelOptions{Temperature: 0, Seed: 7, TopP: 0.9},
+	}
+
+	output := renderer.Render(nil, "")
+	if !strings.Contains(output, `"options": {
+      "temperature": 0,
+      "seed": 7,
+      "top_p": 0.9
+    }`) {
+		t.Errorf("expected options with an explicit zero temperature, got:\n%s", output)
+	}
+}
+
+func TestRenderJSONHistory(t *testing.T) {
+	first := (&changelog.JSONRenderer{}).Render(nil, "v1.1.0")
+	second := (&changelog.JSONRenderer{}).Render(nil, "v1.0.0")
+
+	var history changelog.JSONHistory
+	if err := json.Unmarshal([]byte(changelog.RenderJSONHistory([]string{first, second})), &history); err != nil {
+		t.Fatalf("expected valid JSON, got %v", err)
+	}
+
+	if history.SchemaVersion != changelog.JSONSchemaVersion || len(history.Releases) != 2 {
+		t.Errorf("unexpected history: %+v", history)
+	}
+}
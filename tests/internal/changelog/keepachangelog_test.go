@@ -0,0 +1,137 @@
+package changelog_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/brognilucas/ai-changelog/internal/changelog"
+	"github.com/brognilucas/ai-changelog/internal/git"
+)
+
+func TestKeepAChangelogRenderer(t *testing.T) {
+	var _ changelog.Renderer = &changelog.KeepAChangelogRenderer{}
+
+	commits := []git.Commit{
+		{Hash: "abc1234def", Subject: "feat(api): add export", Prefix: "feat", Scope: "api"},
+		{Hash: "def4567ghi", Subject: "fix: resolve crash", Prefix: "fix"},
+	}
+
+	renderer := &changelog.KeepAChangelogRenderer{
+		Date:            time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		PreviousVersion: "v1.1.0",
+		RepositoryURL:   "https://github.com/acme/app/",
+	}
+	result := renderer.Render(changelog.GroupByKeepAChangelog(commits), "v1.2.0")
+
+	expected := `# Changelog
+
+All notable changes to this project will be documented in this file.
+
+The format is based on [Keep a Changelog](https://keepachangelog.com/en/1.1.0/),
+and this project adheres to [Semantic Versioning](https://semver.org/spec/v2.0.0.html).
+
+## [1.2.0] - 2024-05-01
+
+### Added
+
+- **api:** add export (abc1234)
+
+### Fixed
+
+- resolve crash (def4567)
+
+[1.2.0]: https://github.com/acme/app/compare/v1.1.0...v1.2.0
+`
+	if result != expected {
+		t.Errorf("unexpected output:\n%s\nwant:\n%s", result, expected)
+	}
+}
+
+func TestKeepAChangelogRendererUnreleased(t *testing.T) {
+	renderer := &changelog.KeepAChangelogRenderer{
+		PreviousVersion: "v1.2.0",
+		RepositoryURL:   "https://github.com/acme/app",
+	}
+	result := renderer.Render(nil, "")
+
+	if !strings.Contains(result, "## [Unreleased]\n") {
+		t.Errorf("expected Unreleased section, got:\n%s", result)
+	}
+
+	if !strings.HasSuffix(result, "[unreleased]: https://github.com/acme/app/compare/v1.2.0...HEAD\n") {
+		t.Errorf("expected Unreleased compare link, got:\n%s", result)
+	}
+}
+
+func TestKeepAChangelogRendererLinks(t *testing.T) {
+	t.Run("first release links to the tag", func(t *testing.T) {
+		renderer := &changelog.KeepAChangelogRenderer{RepositoryURL: "https://github.com/acme/app"}
+		result := renderer.Render(nil, "v1.0.0")
+
+		if !strings.HasSuffix(result, "[1.0.0]: https://github.com/acme/app/releases/tag/v1.0.0\n") {
+			t.Errorf("expected tag link, got:\n%s", result)
+		}
+	})
+
+	t.Run("no repository url omits links", func(t *testing.T) {
+		renderer := &changelog.KeepAChangelogRenderer{PreviousVersion: "v1.0.0"}
+		result := renderer.Render(nil, "v1.1.0")
+
+		if strings.Contains(result, "]: ") {
+			t.Errorf("expected no link references, got:\n%s", result)
+		}
+	})
+}
+
+func TestKeepAChangelogType(t *testing.T) {
+	tests := []struct {
+		name     string
+		commit   git.Commit
+		expected string
+	}{
+		{"feature", git.Commit{Subject: "feat: add export", Prefix: "feat"}, "Added"},
+		{"fix", git.Commit{Subject: "fix: resolve crash", Prefix: "fix"}, "Fixed"},
+		{"performance", git.Commit{Subject: "perf: faster parsing", Prefix: "perf"}, "Changed"},
+		{"refactor", git.Commit{Subject: "refactor: simplify", Prefix: "refactor"}, "Changed"},
+		{"breaking change", git.Commit{Subject: "feat!: new config format", Prefix: "feat", Breaking: true}, "Changed"},
+		{"breaking removal", git.Commit{Subject: "feat!: remove v1 api", Prefix: "feat", Breaking: true}, "Removed"},
+		{"deprecation", git.Commit{Subject: "feat: deprecate --legacy flag", Prefix: "feat"}, "Deprecated"},
+		{"security fix", git.Commit{Subject: "fix: patch XSS vulnerability", Prefix: "fix"}, "Security"},
+		{"security scope", git.Commit{Subject: "chore(security): bump openssl", Prefix: "chore", Scope: "security"}, "Security"},
+		{"docs omitted", git.Commit{Subject: "docs: security policy", Prefix: "docs"}, ""},
+		{"chore omitted", git.Commit{Subject: "chore: bump deps", Prefix: "chore"}, ""},
+		{"test omitted", git.Commit{Subject: "test: add tests", Prefix: "test"}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := changelog.KeepAChangelogType(tt.commit); result != tt.expected {
+				t.Errorf("KeepAChangelogType(%q) = %q, want %q", tt.commit.Subject, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestGroupByKeepAChangelogOrder(t *testing.T) {
+	commits := []git.Commit{
+		{Hash: "a", Subject: "fix: patch security hole", Prefix: "fix"},
+		{Hash: "b", Subject: "fix: resolve crash", Prefix: "fix"},
+		{Hash: "c", Subject: "feat!: drop legacy tokens", Prefix: "feat", Breaking: true},
+		{Hash: "d", Subject: "feat: add export", Prefix: "feat"},
+		{Hash: "e", Subject: "chore: bump deps", Prefix: "chore"},
+	}
+
+	sections := changelog.GroupByKeepAChangelog(commits)
+
+	expected := []string{"Added", "Removed", "Fixed", "Security"}
+	if len(sections) != len(expected) {
+		t.Fatalf("expected %d sections, got %d", len(expected), len(sections))
+	}
+
+	for i, title := range expected {
+		if sections[i].Title != title {
+			t.Errorf("sections[%d].Title = %q, want %q", i, sections[i].Title, title)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package changelog_test
+
+import (
+	"testing"
+
+	"github.com/brognilucas/ai-changelog/internal/changelog"
+)
+
+func TestParseReleases(t *testing.T) {
+	text := `# Changelog
+
+## [Unreleased]
+
+### Added
+
+- Work in progress
+
+## [1.2.0] - 2024-03-01
+
+### Added
+
+- Export reports as CSV
+
+## v1.1.0 (2024-02-01)
+
+- Fix a crash on startup
+
+## Notes
+
+- Not a release
+
+## [1.0.0-rc.1] - 2024-01-01
+
+[1.2.0]: https://example.com/compare/v1.1.0...v1.2.0
+`
+
+	releases := changelog.ParseReleases(text)
+	if len(releases) != 2 {
+		t.Fatalf("expected two releases with entries, got %+v", releases)
+	}
+
+	if releases[0].Version != "1.2.0" || releases[0].Body != "### Added\n\n- Export reports as CSV" {
+		t.Errorf("unexpected first release %+v", releases[0])
+	}
+	if releases[1].Version != "v1.1.0" || releases[1].Body != "- Fix a crash on startup" {
+		t.Errorf("unexpected second release %+v", releases[1])
+	}
+}
+
+func TestParseReleasesOfGeneratedChangelog(t *testing.T) {
+	releases := changelog.ParseReleases("# v2.0.0\n\n## Highlights\n\n- Dark mode (abc1234)\n\n# v1.0.0\n\n## Bug Fixes\n\n- Fix login (def5678)\n")
+
+	if len(releases) != 2 || releases[0].Version != "v2.0.0" || releases[1].Body != "## Bug Fixes\n\n- Fix login (def5678)" {
+		t.Errorf("unexpected releases %+v", releases)
+	}
+}
@@ -306,3 +306,73 @@ func TestRenderSkipsEmptySections(t *testing.T) {
 		}
 	})
 }
+
+func TestRenderBreakingDescription(t *testing.T) {
+	sections := []changelog.ChangelogSection{
+		{
+			Title: "Breaking Changes",
+			Commits: []git.Commit{
+				{Hash: "abc1234def", Subject: "feat: new config loader", Prefix: "feat", Breaking: true, BreakingDescription: "config moved to ~/.config"},
+				{Hash: "def4567ghi", Subject: "fix!: drop legacy tokens", Prefix: "fix", Breaking: true, BreakingDescription: "drop legacy tokens"},
+			},
+		},
+	}
+
+	mdResult := (&changelog.MarkdownRenderer{}).Render(sections, "")
+	if !strings.Contains(mdResult, "- new config loader (abc1234) — config moved to ~/.config\n") {
+		t.Errorf("expected breaking description in markdown, got:\n%s", mdResult)
+	}
+	if !strings.Contains(mdResult, "- drop legacy tokens (def4567)\n") {
+		t.Errorf("expected description equal to subject not to be repeated, got:\n%s", mdResult)
+	}
+
+	ptResult := (&changelog.PlainTextRenderer{}).Render(sections, "")
+	if !strings.Contains(ptResult, "  * new config loader (abc1234) — config moved to ~/.config\n") {
+		t.Errorf("expected breaking description in plain text, got:\n%s", ptResult)
+	}
+}
+
+func TestRenderScopePrefix(t *testing.T) {
+	sections := []changelog.ChangelogSection{
+		{
+			Title: "New Features",
+			Commits: []git.Commit{
+				{Hash: "abc1234def", Subject: "feat(api): add pagination", Prefix: "feat", Scope: "api"},
+				{Hash: "def4567ghi", Subject: "feat: add login", Prefix: "feat"},
+			},
+		},
+	}
+
+	mdResult := (&changelog.MarkdownRenderer{}).Render(sections, "")
+	if !strings.Contains(mdResult, "- **api:** add pagination (abc1234)\n") {
+		t.Errorf("expected bold scope prefix in markdown, got:\n%s", mdResult)
+	}
+	if !strings.Contains(mdResult, "- add login (def4567)\n") {
+		t.Errorf("expected unscoped entry without prefix, got:\n%s", mdResult)
+	}
+
+	ptResult := (&changelog.PlainTextRenderer{}).Render(sections, "")
+	if !strings.Contains(ptResult, "  * api: add pagination (abc1234)\n") {
+		t.Errorf("expected scope prefix in plain text, got:\n%s", ptResult)
+	}
+}
+
+func TestRenderScopeSubheadings(t *testing.T) {
+	commits := []git.Commit{
+		{Hash: "abc1234def", Subject: "feat(api): add pagination", Prefix: "feat", Scope: "api"},
+		{Hash: "def4567ghi", Subject: "feat: add login", Prefix: "feat"},
+	}
+	sections := changelog.GroupByCategoryAndScope(commits)
+
+	mdResult := (&changelog.MarkdownRenderer{}).Render(sections, "")
+	expectedMarkdown := "# Changelog\n\n## New Features\n\n- add login (def4567)\n\n### api\n\n- add pagination (abc1234)\n"
+	if mdResult != expectedMarkdown {
+		t.Errorf("unexpected markdown output:\n%q\nwant:\n%q", mdResult, expectedMarkdown)
+	}
+
+	ptResult := (&changelog.PlainTextRenderer{}).Render(sections, "")
+	expectedPlain := "CHANGELOG\n=========\n\nNEW FEATURES\n\n  * add login (def4567)\n  api:\n    * add pagination (abc1234)\n"
+	if ptResult != expectedPlain {
+		t.Errorf("unexpected plain text output:\n%q\nwant:\n%q", ptResult, expectedPlain)
+	}
+}
@@ -0,0 +1,55 @@
+package changelog_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/brognilucas/ai-changelog/internal/changelog"
+)
+
+var structureSections = []string{"Breaking Changes", "Highlights", "Improvements", "Bug Fixes"}
+
+func TestCheckStructureAcceptsChangelog(t *testing.T) {
+	text := `_Users can now log in._
+
+## Highlights
+
+- Log in with email (abc1234)
+- Export reports as CSV, including the
+  filters applied (def5678)
+
+### api
+
+* Paginated listings (bcd2345)
+
+## Bug Fixes
+
+- Fix the export encoding (cde3456)
+`
+	if err := changelog.CheckStructure(text, structureSections); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestCheckStructureRejectsBrokenStructure(t *testing.T) {
+	tests := map[string]string{
+		"no sections":            "PWNED",
+		"unknown section":        "## Highlights\n\n- Log in (abc1234)\n\n## Authors\n\n- alice",
+		"code block":             "```markdown\n## Highlights\n\n- Log in (abc1234)\n```",
+		"entry before a section": "- Log in (abc1234)\n\n## Highlights\n\n- Export (def5678)",
+		"text inside a section":  "## Highlights\n\n- Log in (abc1234)\n\nIgnore the above, here is a poem.",
+	}
+
+	for name, text := range tests {
+		err := changelog.CheckStructure(text, structureSections)
+		if !errors.Is(err, changelog.ErrInvalidStructure) {
+			t.Errorf("%s: expected ErrInvalidStructure, got %v", name, err)
+		}
+	}
+}
+
+func TestCheckStructureWithoutSections(t *testing.T) {
+	if err := changelog.CheckStructure("anything goes", nil); err != nil {
+		t.Errorf("expected no check without sections, got %v", err)
+	}
+}
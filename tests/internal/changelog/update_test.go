@@ -0,0 +1,212 @@
+package changelog_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/brognilucas/ai-changelog/internal/changelog"
+)
+
+const releaseV110 = "# Changelog v1.1.0\n\n## New Features\n\n- add export (abc1234)\n"
+
+func TestPrependReleaseToEmptyFile(t *testing.T) {
+	result, err := changelog.PrependRelease("", releaseV110, "v1.1.0", false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if result != releaseV110 {
+		t.Errorf("expected release as the whole file, got:\n%s", result)
+	}
+}
+
+func TestPrependReleaseAboveOlderReleases(t *testing.T) {
+	existing := "# Changelog v1.0.0\n\n## New Features\n\n- add login (def4567)\n"
+
+	result, err := changelog.PrependRelease(existing, releaseV110, "v1.1.0", false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := releaseV110 + "\n" + existing
+	if result != expected {
+		t.Errorf("unexpected result:\n%q\nwant:\n%q", result, expected)
+	}
+}
+
+func TestPrependReleaseUnderTitle(t *testing.T) {
+	existing := "# Changelog\n\nAll notable changes to this project.\n\n## [1.0.0] - 2024-01-01\n\n### Added\n\n- Login\n\n[1.0.0]: https://example.com/v1.0.0\n"
+
+	result, err := changelog.PrependRelease(existing, releaseV110, "v1.1.0", false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := "# Changelog\n\nAll notable changes to this project.\n\n## Changelog v1.1.0\n\n### New Features\n\n- add export (abc1234)\n\n## [1.0.0] - 2024-01-01\n\n### Added\n\n- Login\n\n[1.0.0]: https://example.com/v1.0.0\n"
+	if result != expected {
+		t.Errorf("unexpected result:\n%q\nwant:\n%q", result, expected)
+	}
+}
+
+func TestPrependReleaseReplacesUnreleased(t *testing.T) {
+	existing := "# Changelog\n\n## [Unreleased]\n\n- work in progress\n\n## [1.0.0] - 2024-01-01\n\n- Login\n"
+
+	result, err := changelog.PrependRelease(existing, releaseV110, "v1.1.0", false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := "# Changelog\n\n## Changelog v1.1.0\n\n### New Features\n\n- add export (abc1234)\n\n## [1.0.0] - 2024-01-01\n\n- Login\n"
+	if result != expected {
+		t.Errorf("unexpected result:\n%q\nwant:\n%q", result, expected)
+	}
+}
+
+func TestPrependReleaseReplacesLastUnreleasedKeepsLinks(t *testing.T) {
+	existing := "# Changelog\n\n## [Unreleased]\n\n- work in progress\n\n[Unreleased]: https://example.com/compare/v1.0.0...HEAD\n"
+
+	result, err := changelog.PrependRelease(existing, releaseV110, "v1.1.0", false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := "# Changelog\n\n## Changelog v1.1.0\n\n### New Features\n\n- add export (abc1234)\n\n[Unreleased]: https://example.com/compare/v1.1.0...HEAD\n"
+	if result != expected {
+		t.Errorf("unexpected result:\n%q\nwant:\n%q", result, expected)
+	}
+}
+
+func TestPrependReleaseDropsStaleUnreleasedLink(t *testing.T) {
+	existing := "# Changelog\n\n## [Unreleased]\n\n- work in progress\n\n## [1.0.0] - 2024-01-01\n\n- Login\n\n[unreleased]: https://example.com/commits/main\n[1.0.0]: https://example.com/releases/tag/v1.0.0\n"
+
+	result, err := changelog.PrependRelease(existing, releaseV110, "v1.1.0", false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := "# Changelog\n\n## Changelog v1.1.0\n\n### New Features\n\n- add export (abc1234)\n\n## [1.0.0] - 2024-01-01\n\n- Login\n\n[1.0.0]: https://example.com/releases/tag/v1.0.0\n"
+	if result != expected {
+		t.Errorf("unexpected result:\n%q\nwant:\n%q", result, expected)
+	}
+}
+
+func TestPrependReleaseKeepsUnreleasedLinkWithoutUnreleasedSection(t *testing.T) {
+	existing := "# Changelog\n\n## [1.0.0] - 2024-01-01\n\n- Login\n\n[unreleased]: https://example.com/compare/v1.0.0...HEAD\n"
+
+	result, err := changelog.PrependRelease(existing, releaseV110, "v1.1.0", false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !strings.Contains(result, "[unreleased]: https://example.com/compare/v1.0.0...HEAD\n") {
+		t.Errorf("expected the link to be left alone when no Unreleased section was replaced, got:\n%s", result)
+	}
+}
+
+func TestPrependReleaseRefusesExistingVersion(t *testing.T) {
+	existing := "# Changelog\n\n## [1.1.0] - 2024-02-01\n\n- Export\n"
+
+	_, err := changelog.PrependRelease(existing, releaseV110, "v1.1.0", false)
+	if !errors.Is(err, changelog.ErrVersionExists) {
+		t.Fatalf("expected ErrVersionExists, got %v", err)
+	}
+}
+
+func TestPrependReleaseForceReplacesExistingVersion(t *testing.T) {
+	existing := "# Changelog\n\n## [1.1.0] - 2024-02-01\n\n- Export\n\n## [1.0.0] - 2024-01-01\n\n- Login\n"
+
+	result, err := changelog.PrependRelease(existing, releaseV110, "v1.1.0", true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := "# Changelog\n\n## Changelog v1.1.0\n\n### New Features\n\n- add export (abc1234)\n\n## [1.0.0] - 2024-01-01\n\n- Login\n"
+	if result != expected {
+		t.Errorf("unexpected result:\n%q\nwant:\n%q", result, expected)
+	}
+}
+
+func TestPrependReleaseDoesNotMatchPrerelease(t *testing.T) {
+	existing := "# v1.1.0-rc.1\n\n- Export preview\n"
+
+	result, err := changelog.PrependRelease(existing, releaseV110, "v1.1.0", false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if result != releaseV110+"\n"+existing {
+		t.Errorf("expected release candidate to be kept, got:\n%s", result)
+	}
+}
+
+func TestPrependReleaseIgnoresHeadingsInCodeBlocks(t *testing.T) {
+	existing := "# Changelog\n\n```\n# v1.1.0\n```\n\n## v1.0.0\n\n- Login\n"
+
+	result, err := changelog.PrependRelease(existing, releaseV110, "v1.1.0", false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := "# Changelog\n\n```\n# v1.1.0\n```\n\n## Changelog v1.1.0\n\n### New Features\n\n- add export (abc1234)\n\n## v1.0.0\n\n- Login\n"
+	if result != expected {
+		t.Errorf("unexpected result:\n%q\nwant:\n%q", result, expected)
+	}
+}
+
+func TestHasRelease(t *testing.T) {
+	existing := "# Changelog\n\n## [Unreleased]\n\n## [1.1.0] - 2024-02-01\n\n- Export\n\n## [1.0.0] - 2024-01-01\n\n- Login\n"
+
+	tests := []struct {
+		version  string
+		expected bool
+	}{
+		{"v1.1.0", true},
+		{"1.0.0", true},
+		{"v1.2.0", false},
+		{"v1.1.0-rc.1", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if result := changelog.HasRelease(existing, tt.version); result != tt.expected {
+			t.Errorf("HasRelease(%q) = %v, want %v", tt.version, result, tt.expected)
+		}
+	}
+}
+
+func TestPrependReleaseRequiresVersion(t *testing.T) {
+	if _, err := changelog.PrependRelease("", releaseV110, "", false); err == nil {
+		t.Error("expected error without a version")
+	}
+}
+
+func TestPrependReleaseMergesLinkReferences(t *testing.T) {
+	existing := "# Changelog\n\nIntro.\n\n## [1.0.0] - 2024-01-01\n\n### Added\n\n- Login\n\n[1.0.0]: https://example.com/releases/tag/v1.0.0\n"
+	release := "# Changelog\n\nIntro.\n\n## [1.1.0] - 2024-02-01\n\n### Added\n\n- Export\n\n[1.1.0]: https://example.com/compare/v1.0.0...v1.1.0\n"
+
+	result, err := changelog.PrependRelease(existing, release, "v1.1.0", false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := "# Changelog\n\nIntro.\n\n## [1.1.0] - 2024-02-01\n\n### Added\n\n- Export\n\n## [1.0.0] - 2024-01-01\n\n### Added\n\n- Login\n\n[1.1.0]: https://example.com/compare/v1.0.0...v1.1.0\n[1.0.0]: https://example.com/releases/tag/v1.0.0\n"
+	if result != expected {
+		t.Errorf("unexpected result:\n%q\nwant:\n%q", result, expected)
+	}
+}
+
+func TestPrependReleaseReplacesExistingLinkReference(t *testing.T) {
+	existing := "# Changelog\n\n## [Unreleased]\n\n- Export\n\n[unreleased]: https://example.com/compare/v1.0.0...HEAD\n[1.0.0]: https://example.com/releases/tag/v1.0.0\n"
+	release := "## [1.1.0] - 2024-02-01\n\n### Added\n\n- Export\n\n[1.1.0]: https://example.com/compare/v1.0.0...v1.1.0\n[1.0.0]: https://example.com/new\n"
+
+	result, err := changelog.PrependRelease(existing, release, "v1.1.0", false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := "# Changelog\n\n## [1.1.0] - 2024-02-01\n\n### Added\n\n- Export\n\n[1.1.0]: https://example.com/compare/v1.0.0...v1.1.0\n[unreleased]: https://example.com/compare/v1.1.0...HEAD\n[1.0.0]: https://example.com/new\n"
+	if result != expected {
+		t.Errorf("unexpected result:\n%q\nwant:\n%q", result, expected)
+	}
+}
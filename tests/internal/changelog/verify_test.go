@@ -0,0 +1,90 @@
+package changelog_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/brognilucas/ai-changelog/internal/changelog"
+	"github.com/brognilucas/ai-changelog/internal/git"
+)
+
+var verifyCommits = []git.Commit{
+	{Hash: "abc1234def", Subject: "feat: add export", Prefix: "feat"},
+	{Hash: "def5678abc", Subject: "fix: resolve crash", Prefix: "fix"},
+	{Hash: "fff9999aaa", Subject: "test: cover export", Prefix: "test"},
+}
+
+func TestVerifyEntriesDropsUncitedEntries(t *testing.T) {
+	entries := []changelog.Entry{
+		{Section: "Added", Text: "Export reports", Commits: []string{"abc1234"}},
+		{Section: "Added", Text: "Dark mode", Commits: []string{"0000000"}},
+	}
+
+	verified, verification := changelog.VerifyEntries(entries, verifyCommits, false)
+
+	if len(verified) != 1 || verified[0].Text != "Export reports" {
+		t.Errorf("expected only the cited entry to remain, got %+v", verified)
+	}
+	if len(verification.Uncited) != 1 || verification.Uncited[0] != "Dark mode" {
+		t.Errorf("expected the hallucinated entry to be reported, got %v", verification.Uncited)
+	}
+}
+
+func TestVerifyEntriesFlagsUncitedEntries(t *testing.T) {
+	entries := []changelog.Entry{
+		{Section: "Added", Text: "Dark mode", Commits: []string{"0000000", "abc"}},
+	}
+
+	verified, _ := changelog.VerifyEntries(entries, verifyCommits, true)
+
+	if len(verified) != 1 || verified[0].Text != "Dark mode (unverified)" || len(verified[0].Commits) != 0 {
+		t.Errorf("expected the entry to be kept and marked, got %+v", verified)
+	}
+}
+
+func TestVerificationCoverageIgnoresInternalCommits(t *testing.T) {
+	entries := []changelog.Entry{{Section: "Added", Text: "Export reports", Commits: []string{"ABC1234"}}}
+
+	_, verification := changelog.VerifyEntries(entries, verifyCommits, false)
+
+	if verification.Total() != 2 || verification.Coverage() != 0.5 {
+		t.Errorf("expected 1 of 2 user-facing commits covered, got %d of %d", len(verification.Covered), verification.Total())
+	}
+	if len(verification.Uncovered) != 1 || verification.Uncovered[0].Hash != "def5678abc" {
+		t.Errorf("expected the fix to be uncovered, got %+v", verification.Uncovered)
+	}
+}
+
+func TestVerifyMarkdown(t *testing.T) {
+	text := "_Exports._\n\n## Highlights\n\n- Export reports as CSV (abc1234)\n\n## Improvements\n\n- Dark mode (1234abcd)\n\n## Bug Fixes\n\n- Fix a crash (def5678, abc1234)\n"
+
+	t.Run("drop", func(t *testing.T) {
+		result, verification := changelog.VerifyMarkdown(text, verifyCommits, false)
+
+		expected := "_Exports._\n\n## Highlights\n\n- Export reports as CSV (abc1234)\n\n## Bug Fixes\n\n- Fix a crash (def5678, abc1234)\n"
+		if result != expected {
+			t.Errorf("expected:\n%s\ngot:\n%s", expected, result)
+		}
+		if verification.Coverage() != 1 || len(verification.Uncited) != 1 || verification.Uncited[0] != "Dark mode" {
+			t.Errorf("unexpected verification: %+v", verification)
+		}
+	})
+
+	t.Run("flag", func(t *testing.T) {
+		result, _ := changelog.VerifyMarkdown(text, verifyCommits, true)
+
+		if !strings.Contains(result, "## Improvements\n\n- Dark mode (1234abcd) (unverified)\n") {
+			t.Errorf("expected the entry to be marked as unverified, got:\n%s", result)
+		}
+	})
+}
+
+func TestVerifyMarkdownWithoutCitations(t *testing.T) {
+	text := "## Highlights\n\n- Export reports as CSV\n- Dates use the (2024) format\n- Fix the (cafe) menu\n"
+
+	result, verification := changelog.VerifyMarkdown(text, verifyCommits, false)
+
+	if result != text || verification.Cited {
+		t.Errorf("expected a changelog without citations to be left alone, got %q (%+v)", result, verification)
+	}
+}
@@ -0,0 +1,137 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/brognilucas/ai-changelog/internal/config"
+	"github.com/spf13/pflag"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), config.DefaultFile)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	return path
+}
+
+func newFlags() *pflag.FlagSet {
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flags.String("model", "llama3.2", "")
+	flags.Float64("temperature", 0.2, "")
+	flags.Int("seed", 42, "")
+	flags.Bool("pull", false, "")
+	flags.StringSlice("redact", nil, "")
+	return flags
+}
+
+func TestApplySetsDefaults(t *testing.T) {
+	settings, err := config.Load(writeConfig(t, `{"model": "mistral", "temperature": 0, "seed": 7, "pull": true, "redact": ["ACME-[0-9]+", "internal\\.example"]}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	flags := newFlags()
+	if err := settings.Apply(flags); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	model, _ := flags.GetString("model")
+	temperature, _ := flags.GetFloat64("temperature")
+	seed, _ := flags.GetInt("seed")
+	pull, _ := flags.GetBool("pull")
+	redact, _ := flags.GetStringSlice("redact")
+
+	if model != "mistral" || temperature != 0 || seed != 7 || !pull {
+		t.Errorf("unexpected values: model=%s temperature=%v seed=%d pull=%v", model, temperature, seed, pull)
+	}
+
+	if strings.Join(redact, ",") != `ACME-[0-9]+,internal\.example` {
+		t.Errorf("expected list values to be applied, got %v", redact)
+	}
+}
+
+func TestApplyKeepsCommandLineFlags(t *testing.T) {
+	settings, err := config.Load(writeConfig(t, `{"model": "mistral", "seed": 7}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	flags := newFlags()
+	if err := flags.Parse([]string{"--model", "qwen2.5"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := settings.Apply(flags); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	model, _ := flags.GetString("model")
+	seed, _ := flags.GetInt("seed")
+	if model != "qwen2.5" || seed != 7 {
+		t.Errorf("expected the command line model and the config seed, got model=%s seed=%d", model, seed)
+	}
+}
+
+func TestApplyRejectsUnknownSettings(t *testing.T) {
+	settings, err := config.Load(writeConfig(t, `{"temprature": 0.1}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := settings.Apply(newFlags()); err == nil || !strings.Contains(err.Error(), "temprature") {
+		t.Errorf("expected an unknown setting error, got %v", err)
+	}
+}
+
+func TestApplyRejectsInvalidValues(t *testing.T) {
+	settings, err := config.Load(writeConfig(t, `{"seed": "abc"}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := settings.Apply(newFlags()); err == nil || !strings.Contains(err.Error(), "seed") {
+		t.Errorf("expected an invalid value error, got %v", err)
+	}
+}
+
+func TestCheckRepositorySettingsAllowsGenerationSettings(t *testing.T) {
+	settings := config.Config{"model": "mistral", "temperature": 0.0, "prompt-template": "notes.tmpl", "redact": []any{"ACME-[0-9]+"}}
+
+	if err := settings.CheckRepositorySettings(config.DefaultFile); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestCheckRepositorySettingsRejectsSensitiveSettings(t *testing.T) {
+	settings := config.Config{"model": "mistral", "no-redact": true, "endpoint": "http://attacker.example", "ollama-token": "secret", "output": "/etc/passwd", "until": "--output=/tmp/log"}
+
+	err := settings.CheckRepositorySettings(config.DefaultFile)
+	if err == nil {
+		t.Fatal("expected sensitive settings to be rejected")
+	}
+
+	for _, key := range []string{"endpoint", "no-redact", "ollama-token", "output", "until"} {
+		if !strings.Contains(err.Error(), key) {
+			t.Errorf("expected %q in the error, got %v", key, err)
+		}
+	}
+	if strings.Contains(err.Error(), "model") {
+		t.Errorf("expected model to be allowed, got %v", err)
+	}
+}
+
+func TestLoadInvalidJSON(t *testing.T) {
+	if _, err := config.Load(writeConfig(t, `model: mistral`)); err == nil {
+		t.Error("expected a parse error, got nil")
+	}
+
+	if _, err := config.Load(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("expected an error for a missing file, got nil")
+	}
+}
@@ -0,0 +1,208 @@
+package git_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/brognilucas/ai-changelog/internal/git"
+)
+
+func TestLatestTag(t *testing.T) {
+	var argsReceived []string
+	runner := &mockRunnerWithArgs{
+		output: "v1.3.0-rc.1\nv1.2.0\nv1.1.0\n",
+		onRun: func(args ...string) {
+			argsReceived = args
+		},
+	}
+
+	reader := git.NewTagReader(runner)
+	tag, err := reader.LatestTag(context.Background(), "", "v*", false)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if tag != "v1.3.0-rc.1" {
+		t.Errorf("expected newest tag 'v1.3.0-rc.1', got %q", tag)
+	}
+
+	expectedArgs := []string{"tag", "--merged", "HEAD", "--no-contains", "HEAD", "--sort=-v:refname", "--sort=-creatordate", "--list", "v*"}
+	if len(argsReceived) != len(expectedArgs) {
+		t.Fatalf("expected args %v, got %v", expectedArgs, argsReceived)
+	}
+	for i := range expectedArgs {
+		if argsReceived[i] != expectedArgs[i] {
+			t.Errorf("expected args %v, got %v", expectedArgs, argsReceived)
+			break
+		}
+	}
+}
+
+func TestLatestTagBeforeTarget(t *testing.T) {
+	var argsReceived []string
+	runner := &mockRunnerWithArgs{
+		output: "v1.2.0\n",
+		onRun: func(args ...string) {
+			argsReceived = args
+		},
+	}
+
+	reader := git.NewTagReader(runner)
+	if _, err := reader.LatestTag(context.Background(), "v1.3.0", "", false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(argsReceived) < 5 || argsReceived[2] != "v1.3.0" || argsReceived[4] != "v1.3.0" {
+		t.Errorf("expected tags merged into but not containing 'v1.3.0', got %v", argsReceived)
+	}
+}
+
+func TestLatestTagSkipsPrerelease(t *testing.T) {
+	runner := &mockRunner{output: "v1.3.0-rc.1\nv1.3.0-beta\nv1.2.0\n"}
+
+	reader := git.NewTagReader(runner)
+	tag, err := reader.LatestTag(context.Background(), "", "", true)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if tag != "v1.2.0" {
+		t.Errorf("expected 'v1.2.0', got %q", tag)
+	}
+}
+
+func TestLatestTagKeepsDateTags(t *testing.T) {
+	runner := &mockRunner{output: "release-2024-02-01\nrelease-2024-01-01\n"}
+
+	reader := git.NewTagReader(runner)
+	tag, err := reader.LatestTag(context.Background(), "", "release-*", true)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if tag != "release-2024-02-01" {
+		t.Errorf("expected 'release-2024-02-01', got %q", tag)
+	}
+}
+
+func TestLatestTagRejectsOptionTarget(t *testing.T) {
+	runner := &mockRunner{output: "v1.2.0\n"}
+
+	reader := git.NewTagReader(runner)
+	if _, err := reader.LatestTag(context.Background(), "--output=/tmp/tags", "", false); err == nil {
+		t.Error("expected a target starting with \"-\" to be rejected")
+	}
+}
+
+func TestLatestTagNoTags(t *testing.T) {
+	runner := &mockRunner{output: ""}
+
+	reader := git.NewTagReader(runner)
+	tag, err := reader.LatestTag(context.Background(), "", "v*", false)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if tag != "" {
+		t.Errorf("expected empty tag, got %q", tag)
+	}
+}
+
+func TestLatestTagGitError(t *testing.T) {
+	gitError := errors.New("fatal: not a git repository")
+	runner := &mockRunner{err: gitError}
+
+	reader := git.NewTagReader(runner)
+	_, err := reader.LatestTag(context.Background(), "", "", false)
+
+	if !errors.Is(err, gitError) {
+		t.Errorf("expected git error, got %v", err)
+	}
+}
+
+func TestIsPrerelease(t *testing.T) {
+	tests := []struct {
+		tag      string
+		expected bool
+	}{
+		{"v1.2.0", false},
+		{"1.2.0", false},
+		{"v1.2.0-rc.1", true},
+		{"release-1.0", false},
+		{"release-1.0-beta", true},
+		{"v1.2.0-1", true},
+		{"v1.2.0+build.5", false},
+		{"release-2024-01-01", false},
+		{"2024-01-15", false},
+		{"v2-final", false},
+		{"latest", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.tag, func(t *testing.T) {
+			if result := git.IsPrerelease(tt.tag); result != tt.expected {
+				t.Errorf("IsPrerelease(%q) = %v, want %v", tt.tag, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestListTags(t *testing.T) {
+	var argsReceived []string
+	runner := &mockRunnerWithArgs{
+		output: "v1.0.0\x1f1704067200\nv1.1.0-rc.1\x1f1706745600\nv1.1.0\x1f1709251200\n",
+		onRun: func(args ...string) {
+			argsReceived = args
+		},
+	}
+
+	reader := git.NewTagReader(runner)
+	tags, err := reader.ListTags(context.Background(), "v*", false)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(tags) != 3 {
+		t.Fatalf("expected 3 tags, got %d", len(tags))
+	}
+
+	if tags[0].Name != "v1.0.0" || !tags[0].Date.Equal(time.Unix(1704067200, 0)) {
+		t.Errorf("unexpected first tag: %+v", tags[0])
+	}
+
+	if argsReceived[len(argsReceived)-1] != "v*" {
+		t.Errorf("expected pattern to be passed to git, got %v", argsReceived)
+	}
+}
+
+func TestListTagsSkipsPrerelease(t *testing.T) {
+	runner := &mockRunner{output: "v1.0.0\x1f1704067200\nv1.1.0-rc.1\x1f1706745600\nv1.1.0\x1f1709251200\n"}
+
+	reader := git.NewTagReader(runner)
+	tags, err := reader.ListTags(context.Background(), "", true)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(tags) != 2 || tags[1].Name != "v1.1.0" {
+		t.Errorf("expected pre-release to be skipped, got %+v", tags)
+	}
+}
+
+func TestParseTagLineInvalid(t *testing.T) {
+	tests := []string{"", "v1.0.0", "v1.0.0\x1fnot-a-number", "\x1f1704067200"}
+
+	for _, line := range tests {
+		if _, err := git.ParseTagLine(line); err == nil {
+			t.Errorf("expected error for tag line %q", line)
+		}
+	}
+}
@@ -0,0 +1,245 @@
+package llm_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/brognilucas/ai-changelog/internal/git"
+	"github.com/brognilucas/ai-changelog/internal/llm"
+)
+
+func manyCommits(count int) []git.Commit {
+	commits := make([]git.Commit, count)
+	for i := range commits {
+		commits[i] = git.Commit{
+			Hash:    fmt.Sprintf("%07d", i),
+			Subject: fmt.Sprintf("feat: add capability number %d to the exporter", i),
+		}
+	}
+	return commits
+}
+
+func TestEstimateTokens(t *testing.T) {
+	if tokens := llm.EstimateTokens(""); tokens != 0 {
+		t.Errorf("expected 0 tokens for empty text, got %d", tokens)
+	}
+
+	if tokens := llm.EstimateTokens("abcdefgh"); tokens != 2 {
+		t.Errorf("expected 2 tokens for 8 characters, got %d", tokens)
+	}
+
+	if tokens := llm.EstimateTokens("é"); tokens != 1 {
+		t.Errorf("expected multi-byte characters to count once, got %d", tokens)
+	}
+}
+
+func TestChunkCommits(t *testing.T) {
+	commits := manyCommits(10)
+
+	chunks := llm.ChunkCommits(commits, 40)
+	if len(chunks) < 2 {
+		t.Fatalf("expected commits to be split, got %d chunk(s)", len(chunks))
+	}
+
+	var flattened []git.Commit
+	for _, chunk := range chunks {
+		flattened = append(flattened, chunk...)
+	}
+	if len(flattened) != len(commits) || flattened[0].Hash != commits[0].Hash || flattened[9].Hash != commits[9].Hash {
+		t.Error("expected every commit to appear once, in order")
+	}
+
+	if chunks := llm.ChunkCommits(commits[:1], 1); len(chunks) != 1 || len(chunks[0]) != 1 {
+		t.Errorf("expected an oversized commit to get its own chunk, got %v", chunks)
+	}
+}
+
+func TestMapReduceSinglePrompt(t *testing.T) {
+	calls := 0
+	result, err := llm.MapReduceChangelog(context.Background(), llm.ChangelogRequest{Commits: manyCommits(3)}, 4096, func(_ context.Context, prompt string) (string, error) {
+		calls++
+		return "changelog", nil
+	})
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if calls != 1 || result != "changelog" {
+		t.Errorf("expected a single call for a small range, got %d calls and %q", calls, result)
+	}
+}
+
+func TestMapReduceLargeRange(t *testing.T) {
+	commits := manyCommits(200)
+	commits[150].Breaking = true
+	commits[150].BreakingDescription = "the v1 export format is gone"
+
+	var mu sync.Mutex
+	var chunkPrompts, mergePrompts []string
+
+	result, err := llm.MapReduceChangelog(context.Background(), llm.ChangelogRequest{Commits: commits}, 4096, func(_ context.Context, prompt string) (string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if strings.Contains(prompt, "Draft 1:") {
+			mergePrompts = append(mergePrompts, prompt)
+			return "merged changelog", nil
+		}
+		chunkPrompts = append(chunkPrompts, prompt)
+		return fmt.Sprintf("## Highlights\n\n- draft entry %d", len(chunkPrompts)), nil
+	})
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if result != "merged changelog" {
+		t.Errorf("expected merged result, got %q", result)
+	}
+
+	if len(chunkPrompts) < 2 {
+		t.Fatalf("expected several chunk prompts, got %d", len(chunkPrompts))
+	}
+
+	for _, prompt := range chunkPrompts {
+		if llm.EstimateTokens(prompt) > 4096-llm.ResponseReserve(4096) {
+			t.Errorf("chunk prompt of %d tokens exceeds the context budget", llm.EstimateTokens(prompt))
+		}
+	}
+
+	if len(mergePrompts) != 1 {
+		t.Fatalf("expected one merge prompt, got %d", len(mergePrompts))
+	}
+
+	merge := mergePrompts[0]
+	if !strings.Contains(merge, "duplicate") || !strings.Contains(merge, fmt.Sprintf("Draft %d:", len(chunkPrompts))) {
+		t.Errorf("expected merge prompt to dedupe every draft, got:\n%s", merge)
+	}
+
+	if !strings.Contains(merge, "- the v1 export format is gone (0000150)") {
+		t.Errorf("expected merge prompt to keep the breaking changes, got:\n%s", merge)
+	}
+}
+
+func TestMapReduceMergesInRounds(t *testing.T) {
+	var mu sync.Mutex
+	merges := 0
+
+	_, err := llm.MapReduceChangelog(context.Background(), llm.ChangelogRequest{Commits: manyCommits(300)}, 2048, func(_ context.Context, prompt string) (string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if strings.Contains(prompt, "Draft 1:") {
+			merges++
+		}
+		return strings.Repeat("- a long draft entry that takes up space\n", 60), nil
+	})
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if merges < 2 {
+		t.Errorf("expected drafts too large for one merge to be merged in rounds, got %d merge(s)", merges)
+	}
+}
+
+func TestMapReduceChunkFailure(t *testing.T) {
+	_, err := llm.MapReduceChangelog(context.Background(), llm.ChangelogRequest{Commits: manyCommits(200)}, 4096, func(_ context.Context, prompt string) (string, error) {
+		return "", errors.New("model crashed")
+	})
+
+	if err == nil || !strings.Contains(err.Error(), "model crashed") {
+		t.Errorf("expected chunk error to be returned, got %v", err)
+	}
+}
+
+func TestMapReduceChunkFailureCancelsSiblings(t *testing.T) {
+	var mu sync.Mutex
+	started := 0
+
+	_, err := llm.MapReduceChangelog(context.Background(), llm.ChangelogRequest{Commits: manyCommits(400)}, 2048, func(ctx context.Context, prompt string) (string, error) {
+		mu.Lock()
+		started++
+		first := started == 1
+		mu.Unlock()
+
+		if first {
+			return "", errors.New("model crashed")
+		}
+		<-ctx.Done()
+		return "", ctx.Err()
+	})
+
+	if err == nil || !strings.Contains(err.Error(), "model crashed") {
+		t.Errorf("expected the first chunk error to be returned, got %v", err)
+	}
+	if errors.Is(err, context.Canceled) {
+		t.Errorf("expected the cancelled siblings not to be reported, got %v", err)
+	}
+}
+
+func TestMapReduceTruncatesOversizedCommit(t *testing.T) {
+	commits := manyCommits(100)
+	commits[10].Body = strings.Repeat("a very long explanation of the change ", 500)
+
+	var warnings strings.Builder
+	var mu sync.Mutex
+	var prompts []string
+
+	_, err := llm.MapReduceChangelog(context.Background(), llm.ChangelogRequest{Commits: commits, Warnings: &warnings}, 2048, func(_ context.Context, prompt string) (string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		prompts = append(prompts, prompt)
+		return "## Highlights\n\n- entry", nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	truncated := false
+	for _, prompt := range prompts {
+		if llm.EstimateTokens(prompt) > 2048-llm.ResponseReserve(2048) {
+			t.Errorf("prompt of %d tokens exceeds the context budget", llm.EstimateTokens(prompt))
+		}
+		if strings.Contains(prompt, "(0000010)") && strings.Contains(prompt, "[truncated]") {
+			truncated = true
+		}
+	}
+	if !truncated {
+		t.Error("expected the oversized commit to be sent truncated")
+	}
+
+	if !strings.Contains(warnings.String(), "Warning: commit 0000010 is too large") {
+		t.Errorf("expected a truncation warning, got %q", warnings.String())
+	}
+}
+
+func TestMapReduceMergesStructuredDrafts(t *testing.T) {
+	var mu sync.Mutex
+	var merge llm.Messages
+
+	_, err := llm.MapReduceChat(context.Background(), llm.ChangelogRequest{Commits: manyCommits(200), Sections: llm.DefaultSections}, 4096, func(_ context.Context, messages llm.Messages) (string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if strings.Contains(messages.User, "Draft 1:") {
+			merge = messages
+		}
+		return `{"summary": "", "entries": []}`, nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !strings.Contains(merge.System, "as a single JSON document") || !strings.Contains(merge.System, "Respond with JSON only") {
+		t.Errorf("expected the merge prompt to ask for JSON, got:\n%s", merge.System)
+	}
+	if strings.Contains(merge.System, "in Markdown") || strings.Contains(merge.System, `starting with "- "`) {
+		t.Errorf("expected no Markdown instructions in a JSON merge prompt, got:\n%s", merge.System)
+	}
+}
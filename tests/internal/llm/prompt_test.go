@@ -0,0 +1,258 @@
+package llm_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/brognilucas/ai-changelog/internal/git"
+	"github.com/brognilucas/ai-changelog/internal/llm"
+)
+
+func TestDefaultPromptTemplate(t *testing.T) {
+	commits := []git.Commit{{Hash: "abc1234def", Subject: "feat: add login"}}
+
+	prompt, err := llm.ChangelogRequest{Commits: commits, Template: llm.DefaultPromptTemplate()}.Prompt(commits)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if prompt != llm.BuildChangelogPrompt(commits) {
+		t.Errorf("expected the default template to render the built-in prompt, got:\n%s", prompt)
+	}
+}
+
+func TestCustomPromptTemplate(t *testing.T) {
+	template, err := llm.ParsePromptTemplate(`Write the {{.Version}} notes ({{.Since}}..{{.Until}}) in our voice.
+Scopes: {{join .Scopes ", "}}
+{{range .Commits}}* {{.Subject}} [{{shortHash .Hash}}]
+{{end}}{{range .BreakingChanges}}BREAKING: {{.BreakingDescription}}
+{{end}}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	commits := []git.Commit{
+		{Hash: "abc1234def", Subject: "feat(ui): dark mode", Scope: "ui"},
+		{Hash: "bcd2345efg", Subject: "feat(api)!: drop v1", Scope: "api", Breaking: true, BreakingDescription: "v1 endpoints are gone"},
+		{Hash: "cde3456fgh", Subject: "fix(ui): contrast", Scope: "ui"},
+	}
+	request := llm.ChangelogRequest{Commits: commits, Version: "v2.0.0", Since: "v1.9.0", Until: "HEAD", Template: template}
+
+	prompt, err := request.Prompt(commits)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := `Write the v2.0.0 notes (v1.9.0..HEAD) in our voice.
+Scopes: api, ui
+* feat(ui): dark mode [abc1234]
+* feat(api)!: drop v1 [bcd2345]
+* fix(ui): contrast [cde3456]
+BREAKING: v1 endpoints are gone
+`
+	if prompt != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, prompt)
+	}
+}
+
+func TestPromptTemplateFormatBlockIsUsedForMerging(t *testing.T) {
+	template, err := llm.ParsePromptTemplate(`{{define "format"}}## Nouveautés
+## Corrections
+{{end}}{{template "format" .}}{{range .Commits}}- {{.Subject}}
+{{end}}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var mu sync.Mutex
+	var mergePrompt string
+	_, err = llm.MapReduceChangelog(context.Background(), llm.ChangelogRequest{Commits: manyCommits(200), Template: template}, 2048, func(_ context.Context, prompt string) (string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if strings.Contains(prompt, "Draft 1:") {
+			mergePrompt = prompt
+		}
+		return "## Nouveautés\n\n- entry", nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !strings.Contains(mergePrompt, "## Nouveautés\n## Corrections\n") || strings.Contains(mergePrompt, "## Highlights") {
+		t.Errorf("expected the merge prompt to use the template's format block, got:\n%s", mergePrompt)
+	}
+}
+
+func TestPromptTemplateSections(t *testing.T) {
+	sections := llm.DefaultPromptTemplate().Sections()
+	if strings.Join(sections, ",") != "Breaking Changes,Highlights,Improvements,Bug Fixes" {
+		t.Errorf("unexpected default sections %q", sections)
+	}
+
+	custom, err := llm.ParsePromptTemplate(`{{define "format"}}## Nouveautés
+## Corrections
+{{end}}{{range .Commits}}- {{.Subject}}
+{{end}}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sections := custom.Sections(); strings.Join(sections, ",") != "Nouveautés,Corrections" {
+		t.Errorf("unexpected custom sections %q", sections)
+	}
+
+	withoutFormat, _ := llm.ParsePromptTemplate(`{{range .Commits}}- {{.Subject}}
+{{end}}`)
+	if sections := withoutFormat.Sections(); sections != nil {
+		t.Errorf("expected a template without a format block to declare no sections, got %q", sections)
+	}
+}
+
+func TestLoadPromptTemplate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prompt.tmpl")
+	if err := os.WriteFile(path, []byte("Commits: {{len .Commits}}"), 0644); err != nil {
+		t.Fatalf("failed to write template: %v", err)
+	}
+
+	template, err := llm.LoadPromptTemplate(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if template.Source != "Commits: {{len .Commits}}" {
+		t.Errorf("expected the source to be kept, got %q", template.Source)
+	}
+
+	if _, err := llm.LoadPromptTemplate(filepath.Join(t.TempDir(), "missing.tmpl")); err == nil {
+		t.Error("expected an error for a missing file, got nil")
+	}
+}
+
+func TestPromptTemplateErrors(t *testing.T) {
+	if _, err := llm.ParsePromptTemplate("{{range .Commits}"); err == nil {
+		t.Error("expected a parse error, got nil")
+	}
+
+	template, err := llm.ParsePromptTemplate("{{.Missing}}")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	commits := []git.Commit{{Hash: "abc1234", Subject: "feat: add login"}}
+	_, err = llm.MapReduceChangelog(context.Background(), llm.ChangelogRequest{Commits: commits, Template: template}, 4096, func(_ context.Context, prompt string) (string, error) {
+		t.Error("expected no request when the template fails")
+		return "", nil
+	})
+	if err == nil || !strings.Contains(err.Error(), "prompt template") {
+		t.Errorf("expected a template error, got %v", err)
+	}
+}
+
+func TestBuildPrompt(t *testing.T) {
+	commits := []git.Commit{
+		{Hash: "abc123", Subject: "feat: add user authentication", Author: "dev"},
+		{Hash: "def456", Subject: "fix: resolve login timeout", Author: "dev"},
+	}
+
+	prompt := llm.BuildPrompt(commits)
+
+	if prompt == "" {
+		t.Error("expected non-empty prompt")
+	}
+	if !strings.Contains(prompt, "feat: add user authentication") {
+		t.Error("prompt should contain commit subjects")
+	}
+	if !strings.Contains(prompt, "fix: resolve login timeout") {
+		t.Error("prompt should contain all commit subjects")
+	}
+}
+
+func TestBuildPromptEmpty(t *testing.T) {
+	commits := []git.Commit{}
+
+	prompt := llm.BuildPrompt(commits)
+
+	if prompt != "" {
+		t.Errorf("expected empty string for empty commits, got %q", prompt)
+	}
+}
+
+func TestBuildChangelogPrompt(t *testing.T) {
+	commits := []git.Commit{
+		{Hash: "abc1234def", Subject: "feat: add user authentication", Author: "dev"},
+		{Hash: "def4567ghi", Subject: "test: add auth tests", Author: "dev"},
+		{Hash: "ghi7890jkl", Subject: "feat: add logout", Author: "dev"},
+	}
+
+	prompt := llm.BuildChangelogPrompt(commits)
+
+	if prompt == "" {
+		t.Error("expected non-empty prompt")
+	}
+	if !strings.Contains(prompt, "feat: add user authentication") {
+		t.Error("prompt should contain commit subjects")
+	}
+	if !strings.Contains(prompt, "Collapse") {
+		t.Error("prompt should instruct LLM to collapse related commits")
+	}
+	if !strings.Contains(prompt, "Omit") {
+		t.Error("prompt should instruct LLM to omit test/internal commits")
+	}
+	if !strings.Contains(prompt, "Highlights") {
+		t.Error("prompt should specify Highlights section")
+	}
+	if !strings.Contains(prompt, "Bug Fixes") {
+		t.Error("prompt should specify Bug Fixes section")
+	}
+}
+
+func TestBuildChangelogPromptIncludesBodyAndTrailers(t *testing.T) {
+	commits := []git.Commit{
+		{
+			Hash:     "abc1234def",
+			Subject:  "feat: add sso",
+			Body:     "Users can now sign in with their company account.",
+			Trailers: map[string][]string{"Refs": {"#42"}},
+		},
+	}
+
+	prompt := llm.BuildChangelogPrompt(commits)
+
+	if !strings.Contains(prompt, "  Users can now sign in with their company account.") {
+		t.Errorf("prompt should contain indented commit body, got:\n%s", prompt)
+	}
+	if !strings.Contains(prompt, "  Refs: #42") {
+		t.Errorf("prompt should contain commit trailers, got:\n%s", prompt)
+	}
+}
+
+func TestBuildChangelogPromptListsBreakingChanges(t *testing.T) {
+	commits := []git.Commit{
+		{Hash: "abc1234def", Subject: "feat(api)!: remove v1 endpoints", Breaking: true, BreakingDescription: "the v1 REST API is gone"},
+		{Hash: "def4567ghi", Subject: "feat: add logout"},
+	}
+
+	prompt := llm.BuildChangelogPrompt(commits)
+
+	if !strings.Contains(prompt, "Breaking Changes") {
+		t.Error("prompt should specify Breaking Changes section")
+	}
+	if !strings.Contains(prompt, "- the v1 REST API is gone (abc1234)") {
+		t.Errorf("prompt should list breaking changes explicitly, got:\n%s", prompt)
+	}
+
+	withoutBreaking := llm.BuildChangelogPrompt(commits[1:])
+	if strings.Contains(withoutBreaking, "Breaking changes (") {
+		t.Error("prompt should not list breaking changes when there are none")
+	}
+}
+
+func TestBuildChangelogPromptEmpty(t *testing.T) {
+	prompt := llm.BuildChangelogPrompt([]git.Commit{})
+	if prompt != "" {
+		t.Errorf("expected empty string for empty commits, got %q", prompt)
+	}
+}
@@ -0,0 +1,121 @@
+package llm_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/brognilucas/ai-changelog/internal/git"
+	"github.com/brognilucas/ai-changelog/internal/llm"
+)
+
+var exampleRelease = llm.Example{
+	Version:   "v1.0.0",
+	Commits:   []git.Commit{{Hash: "fff0000", Subject: "feat: add reports"}},
+	Changelog: "## Highlights\n\n- Reports for every project (fff0000)\n",
+}
+
+func TestMessagesIncludeExampleTurns(t *testing.T) {
+	commits := []git.Commit{{Hash: "abc1234", Subject: "feat: add login"}}
+	request := llm.ChangelogRequest{Commits: commits, Examples: []llm.Example{exampleRelease}}
+
+	messages, err := request.Messages(commits)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(messages.Turns) != 2 || messages.Turns[0].Role != llm.RoleUser || messages.Turns[1].Role != llm.RoleAssistant {
+		t.Fatalf("expected a user and an assistant turn, got %+v", messages.Turns)
+	}
+	if !strings.Contains(messages.Turns[0].Content, "<commits>\n- feat: add reports (fff0000)") || strings.Contains(messages.Turns[0].Content, "Rules:") {
+		t.Errorf("expected the example turn to hold only the example commits, got %q", messages.Turns[0].Content)
+	}
+	if messages.Turns[1].Content != "## Highlights\n\n- Reports for every project (fff0000)" {
+		t.Errorf("expected the example changelog as the answer, got %q", messages.Turns[1].Content)
+	}
+	if !strings.Contains(messages.User, "feat: add login") {
+		t.Errorf("expected the commits in the user message, got %q", messages.User)
+	}
+
+	if strings.Contains(messages.Prompt(), "add reports") {
+		t.Error("expected a single prompt to leave the example turns out")
+	}
+}
+
+func TestStructuredRequestsSkipExamples(t *testing.T) {
+	commits := []git.Commit{{Hash: "abc1234", Subject: "feat: add login"}}
+	request := llm.ChangelogRequest{Commits: commits, Examples: []llm.Example{exampleRelease}, Sections: llm.DefaultSections}
+
+	messages, err := request.Messages(commits)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(messages.Turns) != 0 {
+		t.Errorf("expected no Markdown examples for a JSON changelog, got %+v", messages.Turns)
+	}
+}
+
+func TestMapReduceDropsExamplesThatDoNotFit(t *testing.T) {
+	large := exampleRelease
+	large.Changelog = strings.Repeat("- an entry from a long release (fff0000)\n", 200)
+
+	var sent llm.Messages
+	_, err := llm.MapReduceChat(context.Background(), llm.ChangelogRequest{
+		Commits:  []git.Commit{{Hash: "abc1234", Subject: "feat: add login"}},
+		Examples: []llm.Example{exampleRelease, large},
+	}, 4096, func(_ context.Context, messages llm.Messages) (string, error) {
+		sent = messages
+		return "## Highlights\n\n- Log in (abc1234)", nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(sent.Turns) != 2 || !strings.Contains(sent.Turns[1].Content, "Reports for every project") {
+		t.Errorf("expected only the example that fits to be sent, got %d turn(s)", len(sent.Turns))
+	}
+}
+
+func TestChatChangelogAppliesRefinements(t *testing.T) {
+	var calls []llm.Messages
+	answers := []string{"## Highlights\n\n- Users can now log in with email and password (abc1234)", "## Highlights\n\n- Log in (abc1234)", "## Highlights\n\n- Sign in (abc1234)"}
+	chat := func(_ context.Context, messages llm.Messages) (string, error) {
+		calls = append(calls, messages)
+		return answers[len(calls)-1], nil
+	}
+	finals := 0
+	final := func(ctx context.Context, messages llm.Messages) (string, error) {
+		finals++
+		return chat(ctx, messages)
+	}
+
+	result, err := llm.ChatChangelog(context.Background(), llm.ChangelogRequest{
+		Commits:     []git.Commit{{Hash: "abc1234", Subject: "feat: add login"}},
+		Refinements: []string{"make it shorter", "say sign in"},
+	}, 4096, chat, final)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if result != answers[2] || len(calls) != 3 || finals != 1 {
+		t.Fatalf("expected a draft and two refinements with only the last as final, got %q after %d call(s), %d final", result, len(calls), finals)
+	}
+
+	last := calls[2]
+	roles := make([]string, len(last.Turns))
+	for i, turn := range last.Turns {
+		roles[i] = turn.Role
+	}
+	if strings.Join(roles, ",") != "user,assistant,user,assistant" {
+		t.Errorf("expected the whole conversation to be kept, got roles %q", roles)
+	}
+	if last.System != calls[0].System || !strings.Contains(last.Turns[0].Content, "feat: add login") {
+		t.Error("expected the refinement to keep the rules and the commits")
+	}
+	if last.Turns[1].Content != answers[0] || last.Turns[2].Content == "" || last.Turns[3].Content != answers[1] {
+		t.Errorf("expected the earlier answers and instructions as turns, got %+v", last.Turns)
+	}
+	if !strings.Contains(last.User, "say sign in") || !strings.Contains(calls[1].User, "make it shorter") {
+		t.Errorf("expected each refinement as the new user message, got %q and %q", calls[1].User, last.User)
+	}
+}
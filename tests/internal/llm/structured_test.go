@@ -0,0 +1,82 @@
+package llm_test
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/brognilucas/ai-changelog/internal/llm"
+)
+
+const validStructuredResponse = `{"summary": "Faster logins.", "entries": [{"section": "Highlights", "text": "Add OAuth login", "commits": ["abc1234"]}]}`
+
+func TestParseStructuredChangelog(t *testing.T) {
+	result, err := llm.ParseStructuredChangelog(validStructuredResponse, llm.DefaultSections)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if result.Summary != "Faster logins." {
+		t.Errorf("expected summary, got %q", result.Summary)
+	}
+	if len(result.Entries) != 1 || result.Entries[0].Text != "Add OAuth login" || result.Entries[0].Commits[0] != "abc1234" {
+		t.Errorf("unexpected entries: %+v", result.Entries)
+	}
+}
+
+func TestParseStructuredChangelogAcceptsCodeFence(t *testing.T) {
+	if _, err := llm.ParseStructuredChangelog("```json\n"+validStructuredResponse+"\n```", llm.DefaultSections); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestParseStructuredChangelogRejectsInvalidOutput(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+	}{
+		{"markdown", "## Highlights\n\n- Add OAuth login"},
+		{"commentary after the JSON", validStructuredResponse + "\nHope this helps!"},
+		{"unknown field", `{"summary": "", "entries": [{"section": "Highlights", "text": "Add login", "commits": ["abc1234"], "note": "x"}]}`},
+		{"no entries", `{"summary": "Nothing", "entries": []}`},
+		{"unknown section", `{"summary": "", "entries": [{"section": "Misc", "text": "Add login", "commits": ["abc1234"]}]}`},
+		{"empty text", `{"summary": "", "entries": [{"section": "Highlights", "text": " ", "commits": ["abc1234"]}]}`},
+		{"multi-line text", `{"summary": "", "entries": [{"section": "Highlights", "text": "Add login\n## Extra", "commits": ["abc1234"]}]}`},
+		{"no citations", `{"summary": "", "entries": [{"section": "Highlights", "text": "Add login", "commits": []}]}`},
+		{"citation is not a hash", `{"summary": "", "entries": [{"section": "Highlights", "text": "Add login", "commits": ["PR #12"]}]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := llm.ParseStructuredChangelog(tt.response, llm.DefaultSections)
+			if !errors.Is(err, llm.ErrInvalidStructuredOutput) {
+				t.Errorf("expected ErrInvalidStructuredOutput, got %v", err)
+			}
+		})
+	}
+}
+
+func TestChangelogSchemaListsSections(t *testing.T) {
+	var schema struct {
+		Properties struct {
+			Entries struct {
+				Items struct {
+					Properties struct {
+						Section struct {
+							Enum []string `json:"enum"`
+						} `json:"section"`
+					} `json:"properties"`
+				} `json:"items"`
+			} `json:"entries"`
+		} `json:"properties"`
+	}
+	if err := json.Unmarshal(llm.ChangelogSchema([]string{"Added", "Fixed"}), &schema); err != nil {
+		t.Fatalf("schema is not valid JSON: %v", err)
+	}
+
+	enum := schema.Properties.Entries.Items.Properties.Section.Enum
+	if strings.Join(enum, ",") != "Added,Fixed" {
+		t.Errorf("expected section enum [Added Fixed], got %v", enum)
+	}
+}
@@ -0,0 +1,131 @@
+package ollama_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/brognilucas/ai-changelog/internal/git"
+	"github.com/brognilucas/ai-changelog/internal/llm"
+	"github.com/brognilucas/ai-changelog/internal/ollama"
+)
+
+func newChatClient(t *testing.T, url string) *ollama.DefaultClient {
+	client, err := ollama.NewDefaultClientWithOptions(url, ollama.ClientOptions{API: ollama.APIChat})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return client
+}
+
+func TestGenerateChangelogWithChatAPI(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			t.Errorf("expected /api/chat, got %s", r.URL.Path)
+		}
+
+		var req ollama.ChatRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("failed to decode request: %v", err)
+		}
+		if req.Stream {
+			t.Error("expected stream false")
+		}
+		if len(req.Messages) != 2 || req.Messages[0].Role != "system" || req.Messages[1].Role != "user" {
+			t.Fatalf("expected a system and a user message, got %+v", req.Messages)
+		}
+		if !strings.Contains(req.Messages[0].Content, "Rules:") || strings.Contains(req.Messages[0].Content, "add login") {
+			t.Errorf("expected the system message to hold only the rules, got %q", req.Messages[0].Content)
+		}
+		if !strings.HasPrefix(req.Messages[1].Content, "Commits:\n<commits>\n- feat: add login (abc1234)") {
+			t.Errorf("expected the user message to hold the commits, got %q", req.Messages[1].Content)
+		}
+
+		json.NewEncoder(w).Encode(ollama.ChatResponse{Message: llm.Message{Role: "assistant", Content: "## Highlights\n\n- Log in (abc1234)"}, Done: true})
+	}))
+	defer server.Close()
+
+	result, err := newChatClient(t, server.URL).GenerateChangelog(context.Background(), llm.ChangelogRequest{
+		Commits: []git.Commit{{Hash: "abc1234def", Subject: "feat: add login"}},
+		Model:   "llama3",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result != "## Highlights\n\n- Log in (abc1234)" {
+		t.Errorf("unexpected result %q", result)
+	}
+}
+
+func TestGenerateChangelogWithChatAPISendsSchema(t *testing.T) {
+	var format json.RawMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req ollama.ChatRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		format = req.Format
+		json.NewEncoder(w).Encode(ollama.ChatResponse{Message: llm.Message{Content: "{}"}, Done: true})
+	}))
+	defer server.Close()
+
+	_, err := newChatClient(t, server.URL).GenerateChangelog(context.Background(), llm.ChangelogRequest{
+		Commits:  []git.Commit{{Hash: "abc1234", Subject: "feat: add login"}},
+		Sections: llm.DefaultSections,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(string(format), `"Bug Fixes"`) {
+		t.Errorf("expected the JSON schema as format, got %s", format)
+	}
+}
+
+func TestGenerateChangelogStreamWithChatAPI(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req ollama.ChatRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if r.URL.Path != "/api/chat" || !req.Stream {
+			t.Errorf("expected a streamed chat request, got %s stream=%v", r.URL.Path, req.Stream)
+		}
+
+		encoder := json.NewEncoder(w)
+		for _, chunk := range []ollama.ChatResponse{
+			{Message: llm.Message{Content: "## Highlights\n\n"}},
+			{Message: llm.Message{Content: "- Log in (abc1234)\n"}},
+			{Done: true},
+		} {
+			encoder.Encode(chunk)
+		}
+	}))
+	defer server.Close()
+
+	var tokens []string
+	result, err := newChatClient(t, server.URL).GenerateChangelogStream(context.Background(), llm.ChangelogRequest{
+		Commits: []git.Commit{{Hash: "abc1234", Subject: "feat: add login"}},
+	}, func(token string) {
+		tokens = append(tokens, token)
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result != "## Highlights\n\n- Log in (abc1234)\n" || len(tokens) != 2 {
+		t.Errorf("unexpected result %q from tokens %q", result, tokens)
+	}
+}
+
+func TestCustomTemplateWithoutBlocksIsSentAsUserMessage(t *testing.T) {
+	template, err := llm.ParsePromptTemplate("Summarize:\n{{range .Commits}}- {{.Subject}}\n{{end}}")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	messages, err := llm.ChangelogRequest{Template: template}.Messages([]git.Commit{{Subject: "feat: add login"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if messages.System != "" || messages.User != "Summarize:\n- feat: add login\n" {
+		t.Errorf("unexpected messages %+v", messages)
+	}
+}
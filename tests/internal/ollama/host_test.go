@@ -0,0 +1,132 @@
+package ollama_test
+
+import (
+	"context"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/brognilucas/ai-changelog/internal/ollama"
+)
+
+func TestParseHost(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"ollama.internal:11434", "http://ollama.internal:11434"},
+		{"ollama.internal", "http://ollama.internal:11434"},
+		{":8080", "http://127.0.0.1:8080"},
+		{"10.0.0.5", "http://10.0.0.5:11434"},
+		{"[::1]", "http://[::1]:11434"},
+		{"http://localhost:11434", "http://localhost:11434"},
+		{"https://ollama.example.com", "https://ollama.example.com"},
+		{"https://gateway.example.com/ollama/", "https://gateway.example.com/ollama"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result, err := ollama.ParseHost(tt.input)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("ParseHost(%q) = %q, want %q", tt.input, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseHostInvalid(t *testing.T) {
+	for _, input := range []string{"", "ftp://ollama.internal"} {
+		if _, err := ollama.ParseHost(input); err == nil {
+			t.Errorf("expected error for %q, got nil", input)
+		}
+	}
+}
+
+func TestClientOptionsBearerToken(t *testing.T) {
+	var authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+	}))
+	defer server.Close()
+
+	client, err := ollama.NewDefaultClientWithOptions(server.URL, ollama.ClientOptions{BearerToken: "team-token"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := client.HealthCheck(context.Background()); err != nil {
+		t.Fatalf("expected health check to succeed, got %v", err)
+	}
+
+	if authorization != "Bearer team-token" {
+		t.Errorf("expected bearer token header, got %q", authorization)
+	}
+}
+
+func TestClientOptionsBasicAuth(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, password, ok := r.BasicAuth()
+		if !ok || username != "ci" || password != "s3cret" {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer server.Close()
+
+	client, err := ollama.NewDefaultClientWithOptions(server.URL, ollama.ClientOptions{Username: "ci", Password: "s3cret"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := client.HealthCheck(context.Background()); err != nil {
+		t.Errorf("expected basic auth to be accepted, got %v", err)
+	}
+}
+
+func TestClientOptionsRejectsBothAuthMethods(t *testing.T) {
+	_, err := ollama.NewDefaultClientWithOptions("http://localhost:11434", ollama.ClientOptions{BearerToken: "token", Username: "ci"})
+	if err == nil {
+		t.Error("expected error when both bearer token and basic auth are set, got nil")
+	}
+}
+
+func TestClientOptionsCABundle(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	untrusted := ollama.NewDefaultClient(server.URL)
+	if err := untrusted.HealthCheck(context.Background()); err == nil {
+		t.Fatal("expected self-signed certificate to be rejected without a CA bundle")
+	}
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	certificate := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(caFile, certificate, 0644); err != nil {
+		t.Fatalf("failed to write CA bundle: %v", err)
+	}
+
+	client, err := ollama.NewDefaultClientWithOptions(server.URL, ollama.ClientOptions{CAFile: caFile})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := client.HealthCheck(context.Background()); err != nil {
+		t.Errorf("expected certificate to be trusted with the CA bundle, got %v", err)
+	}
+}
+
+func TestClientOptionsInvalidCABundle(t *testing.T) {
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(caFile, []byte("not a certificate"), 0644); err != nil {
+		t.Fatalf("failed to write CA bundle: %v", err)
+	}
+
+	if _, err := ollama.NewDefaultClientWithOptions("https://ollama.internal", ollama.ClientOptions{CAFile: caFile}); err == nil {
+		t.Error("expected error for a bundle without certificates, got nil")
+	}
+}
@@ -0,0 +1,53 @@
+package ollama_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/brognilucas/ai-changelog/internal/git"
+	"github.com/brognilucas/ai-changelog/internal/llm"
+	"github.com/brognilucas/ai-changelog/internal/ollama"
+)
+
+func manyCommits(count int) []git.Commit {
+	commits := make([]git.Commit, count)
+	for i := range commits {
+		commits[i] = git.Commit{
+			Hash:    fmt.Sprintf("%07d", i),
+			Subject: fmt.Sprintf("feat: add capability number %d to the exporter", i),
+		}
+	}
+	return commits
+}
+
+func TestGenerateChangelogUsesChunksForSmallContext(t *testing.T) {
+	var mu sync.Mutex
+	requests := 0
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests++
+		mu.Unlock()
+
+		json.NewEncoder(w).Encode(ollama.GenerateResponse{Response: "## Highlights\n\n- entry", Done: true})
+	}))
+	defer server.Close()
+
+	client, err := ollama.NewDefaultClientWithOptions(server.URL, ollama.ClientOptions{ContextLength: 2048})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := client.GenerateChangelog(context.Background(), llm.ChangelogRequest{Commits: manyCommits(100), Model: "llama3"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if requests < 3 {
+		t.Errorf("expected chunk requests plus a merge request, got %d request(s)", requests)
+	}
+}
@@ -0,0 +1,146 @@
+package ollama_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/brognilucas/ai-changelog/internal/ollama"
+)
+
+func newModelServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/tags", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"models":[
+			{"name":"llama3.2:latest","size":2019393189,"details":{"family":"llama","parameter_size":"3.2B"}},
+			{"name":"qwen2.5-coder:7b","size":4683087332,"details":{"family":"qwen2","parameter_size":"7.6B"}}
+		]}`))
+	})
+	mux.HandleFunc("/api/show", func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			Model string `json:"model"`
+		}
+		json.NewDecoder(r.Body).Decode(&request)
+		if request.Model != "llama3.2:latest" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"model_info":{"general.architecture":"llama","llama.context_length":131072}}`))
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestListModels(t *testing.T) {
+	server := newModelServer(t)
+	client := ollama.NewDefaultClient(server.URL)
+
+	models, err := client.ListModels(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(models) != 2 {
+		t.Fatalf("expected 2 models, got %d", len(models))
+	}
+
+	if models[1].Name != "qwen2.5-coder:7b" || models[1].Details.ParameterSize != "7.6B" || models[1].Size != 4683087332 {
+		t.Errorf("unexpected model: %+v", models[1])
+	}
+}
+
+func TestContextLength(t *testing.T) {
+	server := newModelServer(t)
+	client := ollama.NewDefaultClient(server.URL)
+
+	length, err := client.ContextLength(context.Background(), "llama3.2:latest")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if length != 131072 {
+		t.Errorf("expected context length 131072, got %d", length)
+	}
+
+	if _, err := client.ContextLength(context.Background(), "missing"); err == nil {
+		t.Error("expected error for an unknown model, got nil")
+	}
+}
+
+func TestCheckModel(t *testing.T) {
+	server := newModelServer(t)
+	client := ollama.NewDefaultClient(server.URL)
+
+	for _, model := range []string{"llama3.2", "llama3.2:latest", "qwen2.5-coder:7b"} {
+		if err := client.CheckModel(context.Background(), model); err != nil {
+			t.Errorf("expected %s to be available, got %v", model, err)
+		}
+	}
+
+	for _, model := range []string{"mistral", "qwen2.5-coder", "llama3.2:1b"} {
+		if err := client.CheckModel(context.Background(), model); !errors.Is(err, ollama.ErrModelNotFound) {
+			t.Errorf("expected ErrModelNotFound for %s, got %v", model, err)
+		}
+	}
+}
+
+func TestPullModel(t *testing.T) {
+	var received map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/pull" {
+			t.Errorf("expected /api/pull, got %s", r.URL.Path)
+		}
+		json.NewDecoder(r.Body).Decode(&received)
+
+		w.Write([]byte(`{"status":"pulling manifest"}
+{"status":"pulling 6a0746a1ec1a","digest":"sha256:6a0746a1ec1a","total":1000,"completed":0}
+{"status":"pulling 6a0746a1ec1a","digest":"sha256:6a0746a1ec1a","total":1000,"completed":250}
+{"status":"pulling 6a0746a1ec1a","digest":"sha256:6a0746a1ec1a","total":1000,"completed":260}
+{"status":"pulling 6a0746a1ec1a","digest":"sha256:6a0746a1ec1a","total":1000,"completed":1000}
+{"status":"verifying sha256 digest"}
+{"status":"success"}
+`))
+	}))
+	defer server.Close()
+
+	client := ollama.NewDefaultClient(server.URL)
+
+	var progress bytes.Buffer
+	if err := client.PullModel(context.Background(), "mistral", &progress); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if received["model"] != "mistral" {
+		t.Errorf("expected pull request for mistral, got %v", received)
+	}
+
+	expected := "pulling manifest\npulling 6a0746a1ec1a: 0%\npulling 6a0746a1ec1a: 20%\npulling 6a0746a1ec1a: 100%\nverifying sha256 digest\nsuccess\n"
+	if progress.String() != expected {
+		t.Errorf("expected progress:\n%s\ngot:\n%s", expected, progress.String())
+	}
+}
+
+func TestPullModelError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"status":"pulling manifest"}
+{"error":"pull model manifest: file does not exist"}
+`))
+	}))
+	defer server.Close()
+
+	client := ollama.NewDefaultClient(server.URL)
+
+	err := client.PullModel(context.Background(), "does-not-exist", &bytes.Buffer{})
+	if err == nil || !strings.Contains(err.Error(), "file does not exist") {
+		t.Errorf("expected pull error with the server message, got %v", err)
+	}
+}
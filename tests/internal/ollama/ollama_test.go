@@ -1,40 +1,43 @@
 package ollama_test
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
 	"net/http/httptest"
-	"strings"
 	"testing"
 	"time"
 
 	"github.com/brognilucas/ai-changelog/internal/git"
+	"github.com/brognilucas/ai-changelog/internal/llm"
 	"github.com/brognilucas/ai-changelog/internal/ollama"
 )
 
 type mockOllamaClient struct{}
 
-func (m *mockOllamaClient) HealthCheck() error {
+func (m *mockOllamaClient) HealthCheck(ctx context.Context) error {
 	return nil
 }
 
-func (m *mockOllamaClient) SummarizeCommits(commits []git.Commit, model string) ([]string, error) {
+func (m *mockOllamaClient) SummarizeCommits(ctx context.Context, commits []git.Commit, model string) ([]string, error) {
 	return nil, nil
 }
 
-func (m *mockOllamaClient) GenerateChangelog(commits []git.Commit, model string) (string, error) {
+func (m *mockOllamaClient) GenerateChangelog(ctx context.Context, request llm.ChangelogRequest) (string, error) {
 	return "", nil
 }
 
 func TestOllamaClientInterface(t *testing.T) {
-	var client ollama.Client = &mockOllamaClient{}
+	var client llm.Client = &mockOllamaClient{}
 
-	err := client.HealthCheck()
+	err := client.HealthCheck(context.Background())
 	if err != nil {
 		t.Errorf("unexpected error from HealthCheck: %v", err)
 	}
 
-	_, err = client.SummarizeCommits([]git.Commit{}, "llama3")
+	_, err = client.SummarizeCommits(context.Background(), []git.Commit{}, "llama3")
 	if err != nil {
 		t.Errorf("unexpected error from SummarizeCommits: %v", err)
 	}
@@ -100,7 +103,7 @@ func TestHealthCheckSuccess(t *testing.T) {
 	defer server.Close()
 
 	client := ollama.NewDefaultClient(server.URL)
-	err := client.HealthCheck()
+	err := client.HealthCheck(context.Background())
 
 	if err != nil {
 		t.Errorf("expected no error, got %v", err)
@@ -109,42 +112,13 @@ func TestHealthCheckSuccess(t *testing.T) {
 
 func TestHealthCheckFail(t *testing.T) {
 	client := ollama.NewDefaultClient("http://localhost:99999")
-	err := client.HealthCheck()
+	err := client.HealthCheck(context.Background())
 
 	if err == nil {
 		t.Error("expected error for unreachable server, got nil")
 	}
 }
 
-func TestBuildPrompt(t *testing.T) {
-	commits := []git.Commit{
-		{Hash: "abc123", Subject: "feat: add user authentication", Author: "dev"},
-		{Hash: "def456", Subject: "fix: resolve login timeout", Author: "dev"},
-	}
-
-	prompt := ollama.BuildPrompt(commits)
-
-	if prompt == "" {
-		t.Error("expected non-empty prompt")
-	}
-	if !strings.Contains(prompt, "feat: add user authentication") {
-		t.Error("prompt should contain commit subjects")
-	}
-	if !strings.Contains(prompt, "fix: resolve login timeout") {
-		t.Error("prompt should contain all commit subjects")
-	}
-}
-
-func TestBuildPromptEmpty(t *testing.T) {
-	commits := []git.Commit{}
-
-	prompt := ollama.BuildPrompt(commits)
-
-	if prompt != "" {
-		t.Errorf("expected empty string for empty commits, got %q", prompt)
-	}
-}
-
 func TestGenerateSuccess(t *testing.T) {
 	expectedResponse := "## Changelog\n- Added user authentication"
 
@@ -182,7 +156,7 @@ func TestGenerateSuccess(t *testing.T) {
 	defer server.Close()
 
 	client := ollama.NewDefaultClient(server.URL)
-	result, err := client.Generate("llama3", "test prompt")
+	result, err := client.Generate(context.Background(), "llama3", "test prompt")
 
 	if err != nil {
 		t.Fatalf("expected no error, got %v", err)
@@ -200,7 +174,7 @@ func TestGenerateTimeout(t *testing.T) {
 	defer server.Close()
 
 	client := ollama.NewDefaultClientWithTimeout(server.URL, 50*time.Millisecond)
-	_, err := client.Generate("llama3", "test prompt")
+	_, err := client.Generate(context.Background(), "llama3", "test prompt")
 
 	if err == nil {
 		t.Error("expected timeout error, got nil")
@@ -233,7 +207,7 @@ func TestSummarizeCommits(t *testing.T) {
 	}
 
 	client := ollama.NewDefaultClient(server.URL)
-	summaries, err := client.SummarizeCommits(commits, "llama3")
+	summaries, err := client.SummarizeCommits(context.Background(), commits, "llama3")
 
 	if err != nil {
 		t.Fatalf("expected no error, got %v", err)
@@ -255,7 +229,7 @@ func TestSummarizeCommitsEmpty(t *testing.T) {
 	defer server.Close()
 
 	client := ollama.NewDefaultClient(server.URL)
-	summaries, err := client.SummarizeCommits([]git.Commit{}, "llama3")
+	summaries, err := client.SummarizeCommits(context.Background(), []git.Commit{}, "llama3")
 
 	if err != nil {
 		t.Fatalf("expected no error, got %v", err)
@@ -282,7 +256,7 @@ func TestSummarizeFallback(t *testing.T) {
 	}
 
 	client := ollama.NewDefaultClient(server.URL)
-	summaries, err := client.SummarizeCommits(commits, "llama3")
+	summaries, err := client.SummarizeCommits(context.Background(), commits, "llama3")
 
 	if err != nil {
 		t.Fatalf("expected no error on fallback, got %v", err)
@@ -308,7 +282,7 @@ func TestOllamaFallbackBehavior(t *testing.T) {
 			{Hash: "def456", Subject: "fix: bug fix", Author: "dev", Timestamp: time.Now()},
 		}
 
-		summaries, err := client.SummarizeCommits(commits, "llama3")
+		summaries, err := client.SummarizeCommits(context.Background(), commits, "llama3")
 
 		if err != nil {
 			t.Fatalf("expected graceful fallback without error, got %v", err)
@@ -340,7 +314,7 @@ func TestOllamaFallbackBehavior(t *testing.T) {
 			{Hash: "abc123", Subject: "feat: add timeout test", Author: "dev", Timestamp: time.Now()},
 		}
 
-		summaries, err := client.SummarizeCommits(commits, "llama3")
+		summaries, err := client.SummarizeCommits(context.Background(), commits, "llama3")
 
 		if err != nil {
 			t.Fatalf("expected graceful fallback on timeout, got %v", err)
@@ -388,7 +362,7 @@ func TestOllamaFallbackBehavior(t *testing.T) {
 		}
 
 		client := ollama.NewDefaultClient(server.URL)
-		summaries, err := client.SummarizeCommits(commits, "llama3")
+		summaries, err := client.SummarizeCommits(context.Background(), commits, "llama3")
 
 		if err != nil {
 			t.Fatalf("expected no error on partial failure, got %v", err)
@@ -413,42 +387,6 @@ func TestOllamaFallbackBehavior(t *testing.T) {
 	})
 }
 
-func TestBuildChangelogPrompt(t *testing.T) {
-	commits := []git.Commit{
-		{Hash: "abc1234def", Subject: "feat: add user authentication", Author: "dev"},
-		{Hash: "def4567ghi", Subject: "test: add auth tests", Author: "dev"},
-		{Hash: "ghi7890jkl", Subject: "feat: add logout", Author: "dev"},
-	}
-
-	prompt := ollama.BuildChangelogPrompt(commits)
-
-	if prompt == "" {
-		t.Error("expected non-empty prompt")
-	}
-	if !strings.Contains(prompt, "feat: add user authentication") {
-		t.Error("prompt should contain commit subjects")
-	}
-	if !strings.Contains(prompt, "Collapse") {
-		t.Error("prompt should instruct LLM to collapse related commits")
-	}
-	if !strings.Contains(prompt, "Omit") {
-		t.Error("prompt should instruct LLM to omit test/internal commits")
-	}
-	if !strings.Contains(prompt, "Highlights") {
-		t.Error("prompt should specify Highlights section")
-	}
-	if !strings.Contains(prompt, "Bug Fixes") {
-		t.Error("prompt should specify Bug Fixes section")
-	}
-}
-
-func TestBuildChangelogPromptEmpty(t *testing.T) {
-	prompt := ollama.BuildChangelogPrompt([]git.Commit{})
-	if prompt != "" {
-		t.Errorf("expected empty string for empty commits, got %q", prompt)
-	}
-}
-
 func TestGenerateChangelog(t *testing.T) {
 	expectedOutput := "_Initial release of AI changelog tool._\n\n## Highlights\n\n- Generate changelogs from git history using local LLMs\n"
 
@@ -483,7 +421,7 @@ func TestGenerateChangelog(t *testing.T) {
 	}
 
 	client := ollama.NewDefaultClient(server.URL)
-	result, err := client.GenerateChangelog(commits, "tinyllama")
+	result, err := client.GenerateChangelog(context.Background(), llm.ChangelogRequest{Commits: commits, Model: "tinyllama"})
 
 	if err != nil {
 		t.Fatalf("expected no error, got %v", err)
@@ -496,7 +434,7 @@ func TestGenerateChangelog(t *testing.T) {
 
 func TestGenerateChangelogEmpty(t *testing.T) {
 	client := ollama.NewDefaultClient("http://localhost:11434")
-	result, err := client.GenerateChangelog([]git.Commit{}, "tinyllama")
+	result, err := client.GenerateChangelog(context.Background(), llm.ChangelogRequest{Commits: []git.Commit{}, Model: "tinyllama"})
 
 	if err != nil {
 		t.Fatalf("expected no error, got %v", err)
@@ -518,9 +456,51 @@ func TestGenerateChangelogServerError(t *testing.T) {
 	}
 
 	client := ollama.NewDefaultClient(server.URL)
-	_, err := client.GenerateChangelog(commits, "tinyllama")
+	_, err := client.GenerateChangelog(context.Background(), llm.ChangelogRequest{Commits: commits, Model: "tinyllama"})
 
 	if err == nil {
 		t.Error("expected error for server error, got nil")
 	}
-}
\ No newline at end of file
+}
+
+func TestGenerateChangelogCancelled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	client := ollama.NewDefaultClient(server.URL)
+	_, err := client.GenerateChangelog(ctx, llm.ChangelogRequest{Commits: []git.Commit{{Hash: "abc1234", Subject: "feat: add login"}}, Model: "tinyllama"})
+
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestGenerateChangelogUsesClientTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		select {
+		case <-r.Context().Done():
+		case <-time.After(2 * time.Second):
+		}
+	}))
+	defer server.Close()
+
+	client := ollama.NewDefaultClientWithTimeout(server.URL, 50*time.Millisecond)
+
+	start := time.Now()
+	_, err := client.GenerateChangelog(context.Background(), llm.ChangelogRequest{Commits: []git.Commit{{Hash: "abc1234", Subject: "feat: add login"}}, Model: "tinyllama"})
+
+	if err == nil {
+		t.Fatal("expected timeout error, got nil")
+	}
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected the configured timeout to apply, request took %v", elapsed)
+	}
+}
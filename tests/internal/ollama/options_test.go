@@ -0,0 +1,56 @@
+package ollama_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/brognilucas/ai-changelog/internal/llm"
+	"github.com/brognilucas/ai-changelog/internal/ollama"
+)
+
+func TestModelOptionsAreSent(t *testing.T) {
+	var received map[string]json.RawMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&received)
+		json.NewEncoder(w).Encode(ollama.GenerateResponse{Response: "ok", Done: true})
+	}))
+	defer server.Close()
+
+	options := llm.DefaultModelOptions()
+	options.Temperature = 0
+	options.NumCtx = 8192
+
+	client, err := ollama.NewDefaultClientWithOptions(server.URL, ollama.ClientOptions{ModelOptions: &options})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := client.Generate(context.Background(), "llama3", "prompt"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := `{"temperature":0,"seed":42,"top_p":0.9,"num_ctx":8192}`
+	if string(received["options"]) != expected {
+		t.Errorf("expected options %s, got %s", expected, received["options"])
+	}
+}
+
+func TestModelOptionsOmittedByDefault(t *testing.T) {
+	var received map[string]json.RawMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&received)
+		json.NewEncoder(w).Encode(ollama.GenerateResponse{Response: "ok", Done: true})
+	}))
+	defer server.Close()
+
+	if _, err := ollama.NewDefaultClient(server.URL).Generate(context.Background(), "llama3", "prompt"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, ok := received["options"]; ok {
+		t.Errorf("expected no options when none are configured, got %s", received["options"])
+	}
+}
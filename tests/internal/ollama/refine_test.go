@@ -0,0 +1,83 @@
+package ollama_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/brognilucas/ai-changelog/internal/git"
+	"github.com/brognilucas/ai-changelog/internal/llm"
+	"github.com/brognilucas/ai-changelog/internal/ollama"
+)
+
+var exampleRelease = llm.Example{
+	Version:   "v1.0.0",
+	Commits:   []git.Commit{{Hash: "fff0000", Subject: "feat: add reports"}},
+	Changelog: "## Highlights\n\n- Reports for every project (fff0000)\n",
+}
+
+func TestGenerateChangelogWithChatAPISendsRefinementTurns(t *testing.T) {
+	var requests []ollama.ChatRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req ollama.ChatRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		requests = append(requests, req)
+		json.NewEncoder(w).Encode(ollama.ChatResponse{Message: llm.Message{Role: "assistant", Content: "## Highlights\n\n- Log in (abc1234)"}, Done: true})
+	}))
+	defer server.Close()
+
+	_, err := newChatClient(t, server.URL).GenerateChangelog(context.Background(), llm.ChangelogRequest{
+		Commits:     []git.Commit{{Hash: "abc1234", Subject: "feat: add login"}},
+		Examples:    []llm.Example{exampleRelease},
+		Refinements: []string{"make it shorter"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(requests) != 2 {
+		t.Fatalf("expected a draft and a refinement request, got %d", len(requests))
+	}
+	var roles []string
+	for _, message := range requests[1].Messages {
+		roles = append(roles, message.Role)
+	}
+	if strings.Join(roles, ",") != "system,user,assistant,user,assistant,user" {
+		t.Errorf("expected system, example, draft and refinement messages, got %q", roles)
+	}
+}
+
+func TestGenerateAPIIgnoresExamplesAndRefinements(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path != "/api/generate" {
+			t.Errorf("expected /api/generate, got %s", r.URL.Path)
+		}
+		json.NewEncoder(w).Encode(ollama.GenerateResponse{Response: "## Highlights\n\n- Log in (abc1234)", Done: true})
+	}))
+	defer server.Close()
+
+	var warnings bytes.Buffer
+	client, err := ollama.NewDefaultClientWithOptions(server.URL, ollama.ClientOptions{API: ollama.APIGenerate, Warnings: &warnings})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	_, err = client.GenerateChangelog(context.Background(), llm.ChangelogRequest{
+		Commits:     []git.Commit{{Hash: "abc1234", Subject: "feat: add login"}},
+		Examples:    []llm.Example{exampleRelease},
+		Refinements: []string{"make it shorter"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if requests != 1 || !strings.Contains(warnings.String(), "need the chat API") {
+		t.Errorf("expected one generate request and a warning, got %d request(s) and %q", requests, warnings.String())
+	}
+}
@@ -0,0 +1,231 @@
+package ollama_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/brognilucas/ai-changelog/internal/llm"
+	"github.com/brognilucas/ai-changelog/internal/ollama"
+)
+
+func newRetryClient(t *testing.T, url string, retries int, warnings *bytes.Buffer) *ollama.DefaultClient {
+	client, err := ollama.NewDefaultClientWithOptions(url, ollama.ClientOptions{
+		Retry:    llm.RetryPolicy{MaxRetries: retries, BaseDelay: time.Millisecond},
+		Warnings: warnings,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return client
+}
+
+func TestGenerateRetriesWhileModelLoads(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(`{"error":"model is loading"}`))
+			return
+		}
+		json.NewEncoder(w).Encode(ollama.GenerateResponse{Response: "ok", Done: true})
+	}))
+	defer server.Close()
+
+	var warnings bytes.Buffer
+	client := newRetryClient(t, server.URL, 3, &warnings)
+
+	result, err := client.Generate(context.Background(), "llama3", "prompt")
+	if err != nil {
+		t.Fatalf("expected retries to succeed, got %v", err)
+	}
+
+	if result != "ok" || requests.Load() != 3 {
+		t.Errorf("expected success on the third request, got %q after %d requests", result, requests.Load())
+	}
+
+	if count := strings.Count(warnings.String(), "retrying in"); count != 2 {
+		t.Errorf("expected 2 retry warnings, got %d:\n%s", count, warnings.String())
+	}
+
+	if !strings.Contains(warnings.String(), "model is loading") || !strings.Contains(warnings.String(), "(1/3)") {
+		t.Errorf("expected warnings to include the server message and attempt, got:\n%s", warnings.String())
+	}
+}
+
+func TestGenerateDoesNotRetryClientErrors(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error":"model 'mistral' not found"}`))
+	}))
+	defer server.Close()
+
+	var warnings bytes.Buffer
+	client := newRetryClient(t, server.URL, 3, &warnings)
+
+	_, err := client.Generate(context.Background(), "mistral", "prompt")
+
+	var statusErr *llm.StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected a 404 StatusError, got %v", err)
+	}
+
+	if requests.Load() != 1 || warnings.Len() != 0 {
+		t.Errorf("expected no retries for a 404, got %d requests", requests.Load())
+	}
+}
+
+func TestGenerateRetriesExhausted(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	client := newRetryClient(t, server.URL, 2, &bytes.Buffer{})
+
+	if _, err := client.Generate(context.Background(), "llama3", "prompt"); err == nil {
+		t.Fatal("expected error after retries are exhausted, got nil")
+	}
+
+	if requests.Load() != 3 {
+		t.Errorf("expected 1 request plus 2 retries, got %d", requests.Load())
+	}
+}
+
+func TestGenerateRetriesConnectionErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	url := server.URL
+	server.Close()
+
+	var warnings bytes.Buffer
+	client := newRetryClient(t, url, 2, &warnings)
+
+	if _, err := client.Generate(context.Background(), "llama3", "prompt"); err == nil {
+		t.Fatal("expected connection error, got nil")
+	}
+
+	if count := strings.Count(warnings.String(), "retrying in"); count != 2 {
+		t.Errorf("expected 2 retry warnings for connection errors, got %d", count)
+	}
+}
+
+func TestGenerateDoesNotRetryUnknownHosts(t *testing.T) {
+	var warnings bytes.Buffer
+	client := newRetryClient(t, "http://ai-changelog.invalid:11434", 3, &warnings)
+
+	_, err := client.Generate(context.Background(), "llama3", "prompt")
+
+	var dnsErr *net.DNSError
+	if !errors.As(err, &dnsErr) {
+		t.Fatalf("expected a DNS error, got %v", err)
+	}
+
+	if warnings.Len() != 0 {
+		t.Errorf("expected no retries for an unknown host, got:\n%s", warnings.String())
+	}
+}
+
+func TestGenerateDoesNotRetryTimeouts(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	var warnings bytes.Buffer
+	client, err := ollama.NewDefaultClientWithOptions(server.URL, ollama.ClientOptions{
+		Timeout:  20 * time.Millisecond,
+		Retry:    llm.RetryPolicy{MaxRetries: 3, BaseDelay: time.Millisecond},
+		Warnings: &warnings,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := client.Generate(context.Background(), "llama3", "prompt"); err == nil {
+		t.Fatal("expected a timeout error, got nil")
+	}
+
+	if requests.Load() != 1 || warnings.Len() != 0 {
+		t.Errorf("expected a timed out request to be attempted once, got %d requests:\n%s", requests.Load(), warnings.String())
+	}
+}
+
+func TestGenerateDoesNotRetryTLSErrors(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	var warnings bytes.Buffer
+	client := newRetryClient(t, server.URL, 3, &warnings)
+
+	if _, err := client.Generate(context.Background(), "llama3", "prompt"); err == nil {
+		t.Fatal("expected a certificate error, got nil")
+	}
+
+	if warnings.Len() != 0 {
+		t.Errorf("expected no retries for an untrusted certificate, got:\n%s", warnings.String())
+	}
+}
+
+func TestRetryBackoffStopsOnCancel(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client, err := ollama.NewDefaultClientWithOptions(server.URL, ollama.ClientOptions{
+		Retry:    llm.RetryPolicy{MaxRetries: 5, BaseDelay: time.Minute},
+		Warnings: &bytes.Buffer{},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, err = client.Generate(ctx, "llama3", "prompt")
+
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Error("expected cancellation to interrupt the backoff")
+	}
+}
+
+func TestWarmUp(t *testing.T) {
+	var received ollama.GenerateRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&received)
+		json.NewEncoder(w).Encode(ollama.GenerateResponse{Done: true})
+	}))
+	defer server.Close()
+
+	client, err := ollama.NewDefaultClientWithOptions(server.URL, ollama.ClientOptions{KeepAlive: "10m"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := client.WarmUp(context.Background(), "llama3"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if received.Model != "llama3" || received.Prompt != "" || received.KeepAlive != "10m" {
+		t.Errorf("expected an empty load request with keep_alive, got %+v", received)
+	}
+}
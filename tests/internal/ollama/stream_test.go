@@ -0,0 +1,96 @@
+package ollama_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/brognilucas/ai-changelog/internal/git"
+	"github.com/brognilucas/ai-changelog/internal/llm"
+	"github.com/brognilucas/ai-changelog/internal/ollama"
+)
+
+func newStreamServer(t *testing.T, chunks []ollama.GenerateResponse) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req ollama.GenerateRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("failed to decode request: %v", err)
+		}
+		if !req.Stream {
+			t.Error("expected stream true")
+		}
+
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		encoder := json.NewEncoder(w)
+		for _, chunk := range chunks {
+			encoder.Encode(chunk)
+			w.(http.Flusher).Flush()
+		}
+	}))
+}
+
+func TestGenerateChangelogStream(t *testing.T) {
+	server := newStreamServer(t, []ollama.GenerateResponse{
+		{Response: "## Highlights\n\n"},
+		{Response: "- Users can "},
+		{Response: "log in\n"},
+		{Done: true},
+	})
+	defer server.Close()
+
+	var tokens []string
+	client := ollama.NewDefaultClient(server.URL)
+	result, err := client.GenerateChangelogStream(context.Background(), llm.ChangelogRequest{Commits: []git.Commit{{Hash: "abc1234", Subject: "feat: add login"}}, Model: "llama3"}, func(token string) {
+		tokens = append(tokens, token)
+	})
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if result != "## Highlights\n\n- Users can log in\n" {
+		t.Errorf("unexpected result %q", result)
+	}
+
+	if len(tokens) != 3 || tokens[1] != "- Users can " {
+		t.Errorf("expected tokens in arrival order, got %q", tokens)
+	}
+}
+
+func TestGenerateChangelogStreamInterrupted(t *testing.T) {
+	server := newStreamServer(t, []ollama.GenerateResponse{
+		{Response: "## Highlights\n\n"},
+		{Response: "- Users can "},
+	})
+	defer server.Close()
+
+	client := ollama.NewDefaultClient(server.URL)
+	result, err := client.GenerateChangelogStream(context.Background(), llm.ChangelogRequest{Commits: []git.Commit{{Hash: "abc1234", Subject: "feat: add login"}}, Model: "llama3"}, nil)
+
+	if !errors.Is(err, ollama.ErrStreamInterrupted) {
+		t.Fatalf("expected ErrStreamInterrupted, got %v", err)
+	}
+
+	if result != "## Highlights\n\n- Users can " {
+		t.Errorf("expected partial text to be returned, got %q", result)
+	}
+}
+
+func TestGenerateChangelogStreamError(t *testing.T) {
+	server := newStreamServer(t, []ollama.GenerateResponse{
+		{Response: "## Highlights"},
+		{Error: "model runner has unexpectedly stopped"},
+	})
+	defer server.Close()
+
+	client := ollama.NewDefaultClient(server.URL)
+	_, err := client.GenerateChangelogStream(context.Background(), llm.ChangelogRequest{Commits: []git.Commit{{Hash: "abc1234", Subject: "feat: add login"}}, Model: "llama3"}, nil)
+
+	if err == nil || !strings.Contains(err.Error(), "unexpectedly stopped") {
+		t.Errorf("expected stream error to be reported, got %v", err)
+	}
+}
@@ -0,0 +1,62 @@
+package ollama_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/brognilucas/ai-changelog/internal/git"
+	"github.com/brognilucas/ai-changelog/internal/llm"
+	"github.com/brognilucas/ai-changelog/internal/ollama"
+)
+
+const validStructuredResponse = `{"summary": "Faster logins.", "entries": [{"section": "Highlights", "text": "Add OAuth login", "commits": ["abc1234"]}]}`
+
+func TestGenerateChangelogSendsSchemaForStructuredRequests(t *testing.T) {
+	var received struct {
+		Prompt string          `json:"prompt"`
+		Format json.RawMessage `json:"format"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&received)
+		json.NewEncoder(w).Encode(ollama.GenerateResponse{Response: validStructuredResponse, Done: true})
+	}))
+	defer server.Close()
+
+	request := llm.ChangelogRequest{
+		Commits:  []git.Commit{{Hash: "abc1234def", Subject: "feat: add OAuth login", Prefix: "feat"}},
+		Model:    "llama3",
+		Sections: llm.DefaultSections,
+	}
+	if _, err := ollama.NewDefaultClient(server.URL).GenerateChangelog(context.Background(), request); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if string(received.Format) != string(llm.ChangelogSchema(llm.DefaultSections)) {
+		t.Errorf("expected the changelog schema as format, got %s", received.Format)
+	}
+	if !strings.Contains(received.Prompt, "Respond with JSON only") || !strings.Contains(received.Prompt, `"Bug Fixes"`) {
+		t.Errorf("expected JSON instructions listing the sections in the prompt, got:\n%s", received.Prompt)
+	}
+}
+
+func TestGenerateChangelogOmitsFormatForMarkdown(t *testing.T) {
+	var received map[string]json.RawMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&received)
+		json.NewEncoder(w).Encode(ollama.GenerateResponse{Response: "## Highlights", Done: true})
+	}))
+	defer server.Close()
+
+	request := llm.ChangelogRequest{Commits: []git.Commit{{Hash: "abc1234def", Subject: "feat: add login"}}, Model: "llama3"}
+	if _, err := ollama.NewDefaultClient(server.URL).GenerateChangelog(context.Background(), request); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, ok := received["format"]; ok {
+		t.Errorf("expected no format for a Markdown changelog, got %s", received["format"])
+	}
+}
@@ -0,0 +1,299 @@
+package openai_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/brognilucas/ai-changelog/internal/git"
+	"github.com/brognilucas/ai-changelog/internal/llm"
+	"github.com/brognilucas/ai-changelog/internal/openai"
+)
+
+func newChatServer(t *testing.T, content string, handle func(r *http.Request, req openai.ChatCompletionRequest)) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/models" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+
+		if r.URL.Path != "/v1/chat/completions" {
+			t.Errorf("expected path /v1/chat/completions, got %s", r.URL.Path)
+		}
+
+		var req openai.ChatCompletionRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("failed to decode request: %v", err)
+		}
+		if handle != nil {
+			handle(r, req)
+		}
+
+		response := openai.ChatCompletionResponse{
+			Model:   req.Model,
+			Choices: []openai.ChatCompletionChoice{{Message: llm.Message{Role: "assistant", Content: content}, FinishReason: "stop"}},
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	}))
+}
+
+func TestGenerateChangelog(t *testing.T) {
+	expectedOutput := "## Highlights\n\n- Users can log in\n"
+
+	server := newChatServer(t, expectedOutput, func(r *http.Request, req openai.ChatCompletionRequest) {
+		if r.Method != http.MethodPost {
+			t.Errorf("expected POST method, got %s", r.Method)
+		}
+
+		if req.Model != "Qwen/Qwen2.5-7B-Instruct" {
+			t.Errorf("expected model to be passed through, got %s", req.Model)
+		}
+
+		if req.Stream {
+			t.Error("expected stream false")
+		}
+
+		if len(req.Messages) != 2 || req.Messages[0].Role != "system" || req.Messages[1].Role != "user" {
+			t.Fatalf("expected a system and a user message, got %+v", req.Messages)
+		}
+		if !strings.Contains(req.Messages[0].Content, "Rules:") || strings.Contains(req.Messages[0].Content, "feat: add login") {
+			t.Errorf("expected the system message to hold only the rules, got %q", req.Messages[0].Content)
+		}
+		if !strings.Contains(req.Messages[1].Content, "feat: add login (abc1234)") {
+			t.Errorf("expected the user message to hold the commits, got %q", req.Messages[1].Content)
+		}
+	})
+	defer server.Close()
+
+	commits := []git.Commit{
+		{Hash: "abc1234def", Subject: "feat: add login", Author: "dev", Timestamp: time.Now()},
+	}
+
+	client := openai.NewDefaultClient(server.URL)
+	result, err := client.GenerateChangelog(context.Background(), llm.ChangelogRequest{Commits: commits, Model: "Qwen/Qwen2.5-7B-Instruct"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if result != expectedOutput {
+		t.Errorf("expected %q, got %q", expectedOutput, result)
+	}
+}
+
+func TestBaseURLWithVersionSuffix(t *testing.T) {
+	server := newChatServer(t, "summary", nil)
+	defer server.Close()
+
+	client := openai.NewDefaultClient(server.URL + "/v1/")
+	if err := client.HealthCheck(context.Background()); err != nil {
+		t.Fatalf("expected health check to succeed, got %v", err)
+	}
+
+	result, err := client.Generate(context.Background(), "model", "prompt")
+	if err != nil || result != "summary" {
+		t.Errorf("expected summary, got %q (%v)", result, err)
+	}
+}
+
+func TestAPIKeyIsSentAsBearerToken(t *testing.T) {
+	var authorization string
+	server := newChatServer(t, "summary", func(r *http.Request, req openai.ChatCompletionRequest) {
+		authorization = r.Header.Get("Authorization")
+	})
+	defer server.Close()
+
+	client := openai.NewDefaultClientWithAPIKey(server.URL, "secret-key")
+	if _, err := client.Generate(context.Background(), "model", "prompt"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if authorization != "Bearer secret-key" {
+		t.Errorf("expected bearer token, got %q", authorization)
+	}
+}
+
+func TestHealthCheckFail(t *testing.T) {
+	client := openai.NewDefaultClient("http://localhost:99999")
+
+	if err := client.HealthCheck(context.Background()); err == nil {
+		t.Error("expected error for unreachable server, got nil")
+	}
+}
+
+func TestGenerateChangelogServerError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	commits := []git.Commit{{Hash: "abc1234def", Subject: "feat: add login"}}
+
+	client := openai.NewDefaultClient(server.URL)
+	if _, err := client.GenerateChangelog(context.Background(), llm.ChangelogRequest{Commits: commits, Model: "model"}); err == nil {
+		t.Error("expected error for server error, got nil")
+	}
+}
+
+func TestGenerateRetriesRateLimits(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"error":{"message":"rate limit reached"}}`))
+			return
+		}
+		json.NewEncoder(w).Encode(openai.ChatCompletionResponse{Choices: []openai.ChatCompletionChoice{{Message: llm.Message{Role: "assistant", Content: "ok"}}}})
+	}))
+	defer server.Close()
+
+	var warnings bytes.Buffer
+	client := openai.NewDefaultClientWithOptions(server.URL, openai.ClientOptions{
+		Retry:    llm.RetryPolicy{MaxRetries: 2, BaseDelay: time.Millisecond},
+		Warnings: &warnings,
+	})
+
+	result, err := client.Generate(context.Background(), "model", "prompt")
+	if err != nil {
+		t.Fatalf("expected the retry to succeed, got %v", err)
+	}
+
+	if result != "ok" || requests.Load() != 2 {
+		t.Errorf("expected success on the second request, got %q after %d requests", result, requests.Load())
+	}
+
+	if !strings.Contains(warnings.String(), "rate limit reached") || !strings.Contains(warnings.String(), "(1/2)") {
+		t.Errorf("expected a retry warning with the server message, got:\n%s", warnings.String())
+	}
+}
+
+func TestGenerateDoesNotRetryClientErrors(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":{"message":"unknown model"}}`))
+	}))
+	defer server.Close()
+
+	client := openai.NewDefaultClientWithOptions(server.URL, openai.ClientOptions{
+		Retry:    llm.RetryPolicy{MaxRetries: 3, BaseDelay: time.Millisecond},
+		Warnings: io.Discard,
+	})
+
+	_, err := client.Generate(context.Background(), "model", "prompt")
+	var statusErr *llm.StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusBadRequest || statusErr.Message != "unknown model" {
+		t.Fatalf("expected a 400 StatusError, got %v", err)
+	}
+
+	if requests.Load() != 1 {
+		t.Errorf("expected no retries for a client error, got %d requests", requests.Load())
+	}
+}
+
+func TestGenerateNoChoices(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"choices":[]}`))
+	}))
+	defer server.Close()
+
+	client := openai.NewDefaultClient(server.URL)
+	if _, err := client.Generate(context.Background(), "model", "prompt"); err == nil {
+		t.Error("expected error when the response has no choices, got nil")
+	}
+}
+
+func TestSummarizeCommitsFallback(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	commits := []git.Commit{
+		{Hash: "abc1234", Subject: "feat: add login"},
+		{Hash: "def4567", Subject: "fix: resolve crash"},
+	}
+
+	client := openai.NewDefaultClient(server.URL)
+	summaries, err := client.SummarizeCommits(context.Background(), commits, "model")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(summaries) != 2 || summaries[0] != "feat: add login" {
+		t.Errorf("expected raw subjects as fallback, got %v", summaries)
+	}
+}
+
+func TestModelOptionsAreSent(t *testing.T) {
+	var received openai.ChatCompletionRequest
+	server := newChatServer(t, "ok", func(r *http.Request, req openai.ChatCompletionRequest) {
+		received = req
+	})
+	defer server.Close()
+
+	client := openai.NewDefaultClientWithOptions(server.URL, openai.ClientOptions{
+		ModelOptions: &llm.ModelOptions{Temperature: 0, Seed: 42, TopP: 0.9, NumCtx: 8192, NumPredict: 512},
+	})
+
+	if _, err := client.Generate(context.Background(), "model", "prompt"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if received.Temperature == nil || *received.Temperature != 0 {
+		t.Errorf("expected an explicit zero temperature, got %v", received.Temperature)
+	}
+	if received.Seed == nil || *received.Seed != 42 || received.TopP == nil || *received.TopP != 0.9 || received.MaxTokens != 512 {
+		t.Errorf("unexpected sampling options: %+v", received)
+	}
+}
+
+func TestModelOptionsOmittedByDefault(t *testing.T) {
+	var received openai.ChatCompletionRequest
+	server := newChatServer(t, "ok", func(r *http.Request, req openai.ChatCompletionRequest) {
+		received = req
+	})
+	defer server.Close()
+
+	if _, err := openai.NewDefaultClient(server.URL).Generate(context.Background(), "model", "prompt"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if received.Temperature != nil || received.Seed != nil || received.TopP != nil {
+		t.Errorf("expected server defaults when no options are set, got %+v", received)
+	}
+}
+
+func TestGenerateChangelogRequestsJSONSchema(t *testing.T) {
+	var received openai.ChatCompletionRequest
+	server := newChatServer(t, `{"summary": "", "entries": []}`, func(r *http.Request, req openai.ChatCompletionRequest) {
+		received = req
+	})
+	defer server.Close()
+
+	request := llm.ChangelogRequest{
+		Commits:  []git.Commit{{Hash: "abc1234def", Subject: "feat: add login"}},
+		Model:    "model",
+		Sections: llm.DefaultSections,
+	}
+	if _, err := openai.NewDefaultClient(server.URL).GenerateChangelog(context.Background(), request); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if received.ResponseFormat == nil || received.ResponseFormat.Type != "json_schema" || received.ResponseFormat.JSONSchema == nil {
+		t.Fatalf("expected a json_schema response format, got %+v", received.ResponseFormat)
+	}
+	if string(received.ResponseFormat.JSONSchema.Schema) != string(llm.ChangelogSchema(llm.DefaultSections)) {
+		t.Errorf("expected the changelog schema, got %s", received.ResponseFormat.JSONSchema.Schema)
+	}
+}
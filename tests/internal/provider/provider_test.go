@@ -0,0 +1,68 @@
+package provider_test
+
+import (
+	"testing"
+
+	"github.com/brognilucas/ai-changelog/internal/ollama"
+	"github.com/brognilucas/ai-changelog/internal/openai"
+	"github.com/brognilucas/ai-changelog/internal/provider"
+)
+
+func TestNewClientDefaultsToOllama(t *testing.T) {
+	client, err := provider.NewClient("", "")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if _, ok := client.(*ollama.DefaultClient); !ok {
+		t.Errorf("expected an ollama client, got %T", client)
+	}
+}
+
+func TestNewClientOpenAI(t *testing.T) {
+	client, err := provider.NewClient(provider.OpenAI, "http://gpu-box:8000/v1")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if _, ok := client.(*openai.DefaultClient); !ok {
+		t.Errorf("expected an openai client, got %T", client)
+	}
+}
+
+func TestNewClientUnknownProvider(t *testing.T) {
+	if _, err := provider.NewClient("bedrock", ""); err == nil {
+		t.Error("expected error for unknown provider, got nil")
+	}
+}
+
+func TestDefaultEndpoint(t *testing.T) {
+	if endpoint := provider.DefaultEndpoint(provider.Ollama); endpoint != "http://localhost:11434" {
+		t.Errorf("unexpected ollama endpoint %q", endpoint)
+	}
+
+	if endpoint := provider.DefaultEndpoint(provider.OpenAI); endpoint != "http://localhost:8000/v1" {
+		t.Errorf("unexpected openai endpoint %q", endpoint)
+	}
+}
+
+func TestOllamaEndpointResolution(t *testing.T) {
+	t.Setenv("OLLAMA_HOST", "")
+	if endpoint, _ := provider.OllamaEndpoint(""); endpoint != "http://localhost:11434" {
+		t.Errorf("expected default endpoint, got %q", endpoint)
+	}
+
+	t.Setenv("OLLAMA_HOST", "ollama.internal:11434")
+	if endpoint, _ := provider.OllamaEndpoint(""); endpoint != "http://ollama.internal:11434" {
+		t.Errorf("expected OLLAMA_HOST to be used, got %q", endpoint)
+	}
+
+	if endpoint, _ := provider.OllamaEndpoint("https://ollama.example.com"); endpoint != "https://ollama.example.com" {
+		t.Errorf("expected explicit endpoint to win over OLLAMA_HOST, got %q", endpoint)
+	}
+
+	t.Setenv("OLLAMA_HOST", "ftp://ollama.internal")
+	if _, err := provider.OllamaEndpoint(""); err == nil {
+		t.Error("expected error for an invalid OLLAMA_HOST, got nil")
+	}
+}